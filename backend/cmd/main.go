@@ -1,27 +1,155 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"math/rand"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"my-source/loto-full/backend/internal/app"
 	"my-source/loto-full/backend/internal/db"
+	handlers "my-source/loto-full/backend/internal/handler"
 	"my-source/loto-full/backend/internal/services"
+	"my-source/loto-full/shared/web"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
 	_ = godotenv.Load()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := handlers.LoadSecrets(); err != nil {
+		log.Fatal(err)
+	}
+
+	noDB := flag.Bool("no-db", false, "keep all data in memory instead of PostgreSQL (dev only)")
+	flag.Parse()
+
 	log.Println("Service run main new")
 	rand.Seed(time.Now().UnixNano())
-	go services.Cleaner()
 
-	app.RegisterRoutes()
-	db.InitPostgres()
+	if err := web.SetTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		log.Fatal("TRUSTED_PROXIES: ", err)
+	}
+
+	cors, err := web.CORSPolicyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	web.SetCORSPolicy(cors)
+
+	var repos *db.Repositories
+	if *noDB {
+		log.Println("⚠️ Running without PostgreSQL, data is kept in memory")
+		repos = db.NewMemory()
+	} else {
+		conn, err := db.InitPostgres(context.Background())
+		if err != nil {
+			log.Fatal("DB init failed: ", err)
+		}
+		repos = db.NewPostgres(conn)
+	}
+
+	history := services.NewHistory(repos.Games, repos.Forced)
+
+	chatURL := os.Getenv("CHAT_SERVER_URL")
+	closer := services.NewCloser(
+		repos.Rooms,
+		repos.Joins,
+		repos.Audit,
+		history,
+		chatURL,
+		handlers.AdminSecret,
+		os.Getenv("PURGE_JOINS_ON_CLOSE") == "true",
+	)
+	go services.Cleaner(repos.Joins, closer, envSeconds("ADMIN_GRACE_SECONDS", 120))
+
+	retention := services.NewRetention(repos.Retention, services.RetentionPolicy{
+		AnonymizeIPsAfter:      envDays("RETENTION_ANONYMIZE_IP_DAYS", 30),
+		DeleteJoinsAfter:       envDays("RETENTION_DELETE_JOINS_DAYS", 180),
+		DeleteClosedRoomsAfter: envDays("RETENTION_DELETE_ROOMS_DAYS", 365),
+	})
+	go retention.Loop()
+
+	persister := services.NewPersister(repos.States, history)
+
+	app.RegisterRoutes(handlers.New(repos, handlers.Services{
+		History:   history,
+		Closer:    closer,
+		Retention: retention,
+
+		PersonalData: services.NewPersonalData(
+			repos.PersonalData,
+			closer,
+			chatURL,
+			handlers.AdminSecret,
+		),
+	}))
+
+	// without a restore the saved games are all we have, so leave them
+	// alone until the next start rather than overwrite them
+	if err := persister.RestoreRooms(context.Background()); err != nil {
+		log.Println("❌ restore rooms, snapshots disabled:", err)
+	} else {
+		closer.CloseStale(context.Background())
+		go persister.Run()
+		go flushOnShutdown(persister)
+	}
 
 	log.Println("✅ Backend running at :8080")
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }
+
+// envDays reads a day count from the environment; 0 disables the rule.
+func envDays(key string, def int) time.Duration {
+	days := def
+	if v := os.Getenv(key); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("invalid %s: %q", key, v)
+		}
+		days = n
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// envSeconds reads a duration in seconds from the environment.
+func envSeconds(key string, def int) time.Duration {
+	secs := def
+	if v := os.Getenv(key); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("invalid %s: %q", key, v)
+		}
+		secs = n
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// flushOnShutdown writes a final snapshot when the container is stopped so
+// deploys don't lose the last few draws.
+func flushOnShutdown(p *services.Persister) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	p.SnapshotRooms(ctx)
+	cancel()
+
+	log.Println("👋 Room state flushed, exiting")
+	os.Exit(0)
+}
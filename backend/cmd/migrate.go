@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"my-source/loto-full/backend/internal/db"
+)
+
+const migrateUsage = `usage: app migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [n]    roll back the last n migrations (default 1)
+  status      print the applied and latest schema versions`
+
+// runMigrate implements the "migrate" subcommand.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+	switch args[0] {
+	case "up", "down", "status":
+	default:
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	conn, err := db.Open()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		return db.Migrate(ctx, conn)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		return db.Rollback(ctx, conn, steps)
+
+	default:
+		current, err := db.SchemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		latest, err := db.LatestVersion()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "applied: %d\nlatest:  %d\n", current, latest)
+		if current > latest {
+			return db.ErrSchemaTooNew{Current: current, Latest: latest}
+		}
+	}
+
+	return nil
+}
@@ -5,4 +5,10 @@ go 1.25.3
 require (
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	my-source/loto-full/shared v0.0.0
 )
+
+require golang.org/x/crypto v0.43.0
+
+replace my-source/loto-full/shared => ../shared
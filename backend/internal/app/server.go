@@ -2,28 +2,66 @@ package app
 
 import (
 	"net/http"
+	"time"
 
+	"my-source/loto-full/backend/internal/core"
 	handlers "my-source/loto-full/backend/internal/handler"
-	utils "my-source/loto-full/backend/internal/utils"
+	"my-source/loto-full/shared/web"
 )
 
-func RegisterRoutes() {
-	http.HandleFunc("/rooms", utils.WithCORS(handlers.ListRooms))
-	http.HandleFunc("/rooms/create", utils.WithCORS(handlers.CreateRoom))
-	http.HandleFunc("/rooms/join", utils.WithCORS(handlers.JoinRoom))
-	http.HandleFunc("/rooms/leave", utils.WithCORS(handlers.LeaveRoom))
-	http.HandleFunc("/rooms/state", utils.WithCORS(handlers.RoomState))
-	http.HandleFunc("/rooms/ping", utils.WithCORS(handlers.PingRoom))
+func RegisterRoutes(h *handlers.Handler) {
+	// Default rate limits; each can be overridden with RATE_LIMIT_<NAME>_IP
+	// and RATE_LIMIT_<NAME>_SESSION.
+	createLimit := web.NewRateLimiter("rooms_create",
+		web.Limit{Burst: 5, Per: time.Minute}, web.Limit{}, handlers.SessionRateKey)
+	joinLimit := web.NewRateLimiter("rooms_join",
+		web.Limit{Burst: 30, Per: time.Minute}, web.Limit{}, handlers.SessionRateKey)
+	bingoLimit := web.NewRateLimiter("rooms_bingo",
+		web.Limit{Burst: 60, Per: time.Minute}, web.Limit{Burst: 10, Per: time.Minute}, handlers.SessionRateKey)
+	lotoLimit := web.NewRateLimiter("rooms_loto",
+		web.Limit{Burst: 300, Per: time.Minute}, web.Limit{Burst: 60, Per: time.Minute}, handlers.SessionRateKey)
 
-	http.HandleFunc("/rooms/start", utils.WithCORS(handlers.StartRoom))
-	http.HandleFunc("/rooms/interval", utils.WithCORS(handlers.SetInterval))
+	http.HandleFunc("/rooms", web.WithCORS(h.ListRooms))
+	http.HandleFunc("/rooms/create", web.WithCORS(createLimit.Wrap(h.CreateRoom)))
+	http.HandleFunc("/rooms/join", web.WithCORS(joinLimit.Wrap(h.JoinRoom)))
+	http.HandleFunc("/rooms/leave", web.WithCORS(handlers.RequireSession(h.LeaveRoom)))
+	http.HandleFunc("/rooms/state", web.WithCORS(handlers.RequireSession(h.RoomState)))
+	http.HandleFunc("/rooms/ping", web.WithCORS(handlers.RequireSession(h.PingRoom)))
 
-	http.HandleFunc("/rooms/bingo", utils.WithCORS(handlers.Bingo))
-	http.HandleFunc("/rooms/bingo/result", utils.WithCORS(handlers.BingoResult))
-	http.HandleFunc("/rooms/restart", utils.WithCORS(handlers.RestartGame))
+	http.HandleFunc("/rooms/start", web.WithCORS(handlers.RequirePermission(core.PermRunGame)(h.StartRoom)))
+	http.HandleFunc("/rooms/hold", web.WithCORS(handlers.RequirePermission(core.PermPause)(h.HoldGame)))
+	http.HandleFunc("/rooms/interval", web.WithCORS(handlers.RequirePermission(core.PermRunGame)(h.SetInterval)))
 
-	http.HandleFunc("/rooms/loto/select", utils.WithCORS(handlers.SelectLoto))
-	http.HandleFunc("/rooms/loto/unselect", utils.WithCORS(handlers.UnselectLoto))
+	http.HandleFunc("/rooms/bingo", web.WithCORS(bingoLimit.Wrap(handlers.RequirePermission(core.PermPlay)(h.Bingo))))
+	http.HandleFunc("/rooms/bingo/result", web.WithCORS(handlers.RequirePermission(core.PermReviewBingo)(h.BingoResult)))
+	http.HandleFunc("/rooms/restart", web.WithCORS(handlers.RequirePermission(core.PermRunGame)(h.RestartGame)))
+	http.HandleFunc("/rooms/kick", web.WithCORS(handlers.RequirePermission(core.PermRemovePlayers)(h.KickPlayer)))
+	http.HandleFunc("/rooms/ban", web.WithCORS(handlers.RequirePermission(core.PermRemovePlayers)(h.BanPlayer)))
+	http.HandleFunc("/rooms/unban", web.WithCORS(handlers.RequirePermission(core.PermRemovePlayers)(h.UnbanPlayer)))
+	http.HandleFunc("/rooms/bans", web.WithCORS(handlers.RequirePermission(core.PermRemovePlayers)(h.ListBans)))
+	http.HandleFunc("/rooms/transfer-admin", web.WithCORS(handlers.RequirePermission(core.PermManageRoles)(h.TransferAdmin)))
+	http.HandleFunc("/rooms/role", web.WithCORS(handlers.RequirePermission(core.PermManageRoles)(h.SetRole)))
+	http.HandleFunc("/rooms/visibility", web.WithCORS(handlers.RequirePermission(core.PermManageRoom)(h.SetVisibility)))
+	http.HandleFunc("/rooms/meta", web.WithCORS(handlers.RequirePermission(core.PermManageRoom)(h.SetRoomMeta)))
+	http.HandleFunc("/rooms/invite", web.WithCORS(handlers.RequirePermission(core.PermManageRoom)(h.CreateInvite)))
+	http.HandleFunc("/rooms/invite/revoke", web.WithCORS(handlers.RequirePermission(core.PermManageRoom)(h.RevokeInvites)))
+	http.HandleFunc("/rooms/invite/qr", web.WithCORS(h.InviteQR))
+	http.HandleFunc("/rooms/cohost", web.WithCORS(handlers.RequirePermission(core.PermManageRoles)(h.SetCoHost)))
 
-	http.HandleFunc("/rooms/force-number", utils.WithCORS(handlers.ForceNumberHandler))
+	http.HandleFunc("/rooms/loto/select", web.WithCORS(lotoLimit.Wrap(handlers.RequirePermission(core.PermPlay)(h.SelectLoto))))
+	http.HandleFunc("/rooms/loto/unselect", web.WithCORS(lotoLimit.Wrap(handlers.RequireSession(h.UnselectLoto))))
+
+	http.HandleFunc("/games/{id}", web.WithCORS(h.GetGame))
+	http.HandleFunc("/games/{id}/replay", web.WithCORS(h.ReplayGame))
+
+	http.HandleFunc("/admin/joins/room", web.WithCORS(handlers.AdminOnly(h.RoomJoins)))
+	http.HandleFunc("/admin/joins/user", web.WithCORS(handlers.AdminOnly(h.UserJoins)))
+	http.HandleFunc("/admin/analytics", web.WithCORS(handlers.AdminOnly(h.JoinAnalytics)))
+	http.HandleFunc("/admin/retention/preview", web.WithCORS(handlers.AdminOnly(h.RetentionPreview)))
+	http.HandleFunc("/admin/users/export", web.WithCORS(handlers.AdminOnly(h.ExportUserData)))
+	http.HandleFunc("/admin/users/erase", web.WithCORS(handlers.AdminOnly(h.EraseUserData)))
+	http.HandleFunc("/admin/rooms/force-number", web.WithCORS(handlers.AdminOnly(h.ForceNumberHandler)))
+	http.HandleFunc("/admin/forced-draws", web.WithCORS(handlers.AdminOnly(h.ForcedDraws)))
+	http.HandleFunc("/admin/audit", web.WithCORS(handlers.AdminOnly(h.AuditEvents)))
+	http.HandleFunc("/admin/metrics/ratelimit", web.WithCORS(handlers.AdminOnly(h.RateLimitMetrics)))
 }
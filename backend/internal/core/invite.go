@@ -0,0 +1,61 @@
+package core
+
+import "time"
+
+// Visibility controls who sees a room in the lobby and how one gets in.
+type Visibility string
+
+const (
+	// VisibilityPublic rooms are listed and anyone may join.
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted rooms need the secret or an invite.
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityPrivate rooms can only be joined with an invite.
+	VisibilityPrivate Visibility = "private"
+)
+
+// ParseVisibility reads a visibility; empty means unlisted, which is how
+// every room behaved before visibility existed.
+func ParseVisibility(s string) (Visibility, bool) {
+	switch v := Visibility(s); v {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return v, true
+	case "":
+		return VisibilityUnlisted, true
+	}
+	return "", false
+}
+
+// AddInviteCode stores a short invite code until exp, dropping codes that
+// have already expired. The caller must hold Mu.
+func (rm *Room) AddInviteCode(code string, exp time.Time) {
+	now := time.Now().Unix()
+	for c, e := range rm.InviteCodes {
+		if e <= now {
+			delete(rm.InviteCodes, c)
+		}
+	}
+	if rm.InviteCodes == nil {
+		rm.InviteCodes = map[string]int64{}
+	}
+	rm.InviteCodes[code] = exp.Unix()
+}
+
+// RevokeInvites voids every invite link and code issued so far.
+// The caller must hold Mu.
+func (rm *Room) RevokeInvites() {
+	rm.InviteGen++
+	rm.InviteCodes = nil
+}
+
+// FindInviteCode returns the room holding an unexpired code.
+// The caller must hold Mu.
+func FindInviteCode(code string) *Room {
+	now := time.Now().Unix()
+	for _, rm := range Rooms {
+		if exp, ok := rm.InviteCodes[code]; ok && exp > now {
+			return rm
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Theme is an event theme the frontend dresses the room in.
+type Theme string
+
+const (
+	ThemeNone      Theme = ""
+	ThemeTet       Theme = "tet"
+	ThemeMidAutumn Theme = "mid_autumn"
+	ThemeChristmas Theme = "christmas"
+)
+
+const (
+	maxTitleLen       = 80
+	maxDescriptionLen = 500
+	maxLanguageLen    = 16
+	maxTags           = 10
+	maxTagLen         = 24
+)
+
+var (
+	ErrTitleTooLong       = errors.New("title too long")
+	ErrDescriptionTooLong = errors.New("description too long")
+	ErrInvalidLanguage    = errors.New("invalid language")
+	ErrInvalidTheme       = errors.New("invalid theme")
+	ErrTooManyTags        = errors.New("too many tags")
+	ErrInvalidTag         = errors.New("invalid tag")
+)
+
+// RoomMeta describes a room in the lobby. All fields are optional.
+type RoomMeta struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Language is a BCP 47 tag such as "vi" or "en-US".
+	Language string   `json:"language"`
+	Theme    Theme    `json:"theme"`
+	Tags     []string `json:"tags"`
+}
+
+// Clean trims m and checks it. Tags are lowercased and deduplicated.
+func (m RoomMeta) Clean() (RoomMeta, error) {
+	m.Title = strings.TrimSpace(m.Title)
+	m.Description = strings.TrimSpace(m.Description)
+	m.Language = strings.TrimSpace(m.Language)
+
+	if utf8.RuneCountInString(m.Title) > maxTitleLen {
+		return m, ErrTitleTooLong
+	}
+	if utf8.RuneCountInString(m.Description) > maxDescriptionLen {
+		return m, ErrDescriptionTooLong
+	}
+	if len(m.Language) > maxLanguageLen || strings.IndexFunc(m.Language, func(r rune) bool {
+		return !(r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) >= 0 {
+		return m, ErrInvalidLanguage
+	}
+	switch m.Theme {
+	case ThemeNone, ThemeTet, ThemeMidAutumn, ThemeChristmas:
+	default:
+		return m, ErrInvalidTheme
+	}
+
+	tags := []string{}
+	for _, t := range m.Tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || slices.Contains(tags, t) {
+			continue
+		}
+		if utf8.RuneCountInString(t) > maxTagLen || strings.ContainsRune(t, ',') {
+			return m, ErrInvalidTag
+		}
+		tags = append(tags, t)
+	}
+	if len(tags) > maxTags {
+		return m, ErrTooManyTags
+	}
+	m.Tags = tags
+
+	return m, nil
+}
+
+// Matches reports whether query appears, ignoring case, in the room id,
+// title, description or one of the tags. An empty query matches.
+// The caller must hold Mu.
+func (rm *Room) Matches(query string) bool {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return true
+	}
+	for _, s := range append([]string{rm.ID, rm.Meta.Title, rm.Meta.Description}, rm.Meta.Tags...) {
+		if strings.Contains(strings.ToLower(s), q) {
+			return true
+		}
+	}
+	return false
+}
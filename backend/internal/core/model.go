@@ -22,9 +22,242 @@ type Room struct {
 	Winner     string               `json:"winner"`
 	WinnerNums string               `json:"winnerNums"`
 	ApprovedAt int64                `json:"approvedAt"`
+	GameID     int64                `json:"gameId"`
 
-	Lotos  map[int]string `json:"lotos"`
-	Secret string         `json:"-"`
+	// Held is a pause by the owner or a moderator; unlike Paused it is
+	// not lifted by reviewing claims.
+	Held bool `json:"held"`
 
-	NextForce int `json:"-"`
+	Lotos map[int]string `json:"lotos"`
+	// Secret is the bcrypt hash of the join secret, never the secret itself.
+	Secret string `json:"-"`
+
+	// AdminNonce is what the key in the admin's token is derived from;
+	// host actions require a token carrying that key. It changes whenever
+	// the room changes hands. The key itself is never stored.
+	AdminNonce string `json:"-"`
+	// AdminPending is set after a hand-off until the new admin has used
+	// the admin token ping hands them. HandedOffAt (unix ms) is when the
+	// hand-off happened; only a session issued before it may collect the
+	// token, so nobody can join under the new admin's name and take it.
+	AdminPending bool  `json:"-"`
+	HandedOffAt  int64 `json:"-"`
+	// CoHost is the player the host picked to take over from them.
+	CoHost string `json:"coHost,omitempty"`
+	// Joined holds when each present user joined; failing a co-host, the
+	// longest-present player takes over.
+	Joined map[string]time.Time `json:"-"`
+	// Roles holds the users who are not plain players; the owner is Admin.
+	Roles map[string]Role `json:"roles,omitempty"`
+	// ModGrants holds the grant behind each moderator in Roles.
+	ModGrants map[string]ModGrant `json:"-"`
+
+	Visibility Visibility `json:"visibility"`
+	Meta       RoomMeta   `json:"meta"`
+	// InviteGen is signed into invite links; bumping it voids them all.
+	InviteGen int `json:"-"`
+	// InviteCodes maps short invite codes to their expiry (unix seconds).
+	InviteCodes map[string]int64 `json:"-"`
+
+	// NextForce is an operator-forced next ball and ForceID its pending
+	// row in the forced_draws audit.
+	NextForce int   `json:"-"`
+	ForceID   int64 `json:"-"`
+	// ForcedSeqs are the positions in Called (1-based) that were forced.
+	ForcedSeqs []int `json:"-"`
+	// ForcedNumbers discloses the forced balls to players once a winner
+	// has been approved.
+	ForcedNumbers []int `json:"forcedNumbers,omitempty"`
+
+	// JoinIPs holds the address each user last joined from, which an IP
+	// ban takes.
+	JoinIPs map[string]string `json:"-"`
+	// Banned and BannedIPs mirror the room_bans rows of this room: the
+	// banned names, and the banned addresses by ban id so lifting a ban
+	// drops its address whatever retention did to the row.
+	Banned    map[string]bool  `json:"-"`
+	BannedIPs map[int64]string `json:"-"`
+	// Kicked holds when (unix ms) each user was last kicked; sessions
+	// issued before that are void.
+	Kicked map[string]int64 `json:"-"`
+}
+
+// Join adds user to the room, or refreshes their ping if already present.
+// The caller must hold Mu.
+func (rm *Room) Join(user string) {
+	now := time.Now()
+	rm.Users[user] = now
+	if rm.Joined == nil {
+		rm.Joined = map[string]time.Time{}
+	}
+	if _, ok := rm.Joined[user]; !ok {
+		rm.Joined[user] = now
+	}
+}
+
+// JoinFrom is Join for a new session, remembering the address it came
+// from. The caller must hold Mu.
+func (rm *Room) JoinFrom(user, ip string) {
+	rm.Join(user)
+	if rm.JoinIPs == nil {
+		rm.JoinIPs = map[string]string{}
+	}
+	rm.JoinIPs[user] = ip
+}
+
+// Leave takes user out of the room's player list. The caller must hold Mu.
+func (rm *Room) Leave(user string) {
+	delete(rm.Users, user)
+	delete(rm.Joined, user)
+}
+
+// Successor picks who takes over from the admin: the co-host if present,
+// else whoever joined longest ago, moderators before players before
+// spectators. Empty when nobody else is left. The caller must hold Mu.
+func (rm *Room) Successor() string {
+	if _, ok := rm.Users[rm.CoHost]; ok && rm.CoHost != rm.Admin {
+		return rm.CoHost
+	}
+
+	rank := map[Role]int{RoleModerator: 0, RolePlayer: 1, RoleSpectator: 2}
+	best := ""
+	for u := range rm.Users {
+		if u == rm.Admin {
+			continue
+		}
+		if best == "" {
+			best = u
+			continue
+		}
+		ru, rb := rank[rm.RoleOf(u)], rank[rm.RoleOf(best)]
+		tu, tb := rm.Joined[u], rm.Joined[best]
+		if ru < rb || (ru == rb && (tu.Before(tb) || (tu.Equal(tb) && u < best))) {
+			best = u
+		}
+	}
+	return best
+}
+
+// HandOff makes user the admin under a new nonce, voiding every earlier
+// admin token. The previous admin stays on as a player. The caller must
+// hold Mu.
+func (rm *Room) HandOff(user, nonce string) {
+	rm.Admin, rm.AdminNonce = user, nonce
+	rm.AdminPending = true
+	rm.HandedOffAt = time.Now().UnixMilli()
+	delete(rm.Roles, user)
+	delete(rm.ModGrants, user)
+	if rm.CoHost == user {
+		rm.CoHost = ""
+	}
+}
+
+// NameTaken reports whether user already stands for someone in the room:
+// a present player, the admin, the co-host, a user holding a role or a
+// loto. JoinRoom will not hand a new session out for such a name. The
+// caller must hold Mu.
+func (rm *Room) NameTaken(user string) bool {
+	if _, ok := rm.Users[user]; ok {
+		return true
+	}
+	if _, ok := rm.Roles[user]; ok {
+		return true
+	}
+	if user == rm.Admin || user == rm.CoHost {
+		return true
+	}
+	for _, owner := range rm.Lotos {
+		if owner == user {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBanned reports whether user, or a player connecting from ip, is banned.
+// The room's admin is never caught by an IP ban. The caller must hold Mu.
+func (rm *Room) IsBanned(user, ip string) bool {
+	if rm.Banned[user] {
+		return true
+	}
+	if user == rm.Admin || ip == "" {
+		return false
+	}
+	for _, banned := range rm.BannedIPs {
+		if banned == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// Quit takes user out of the room for good: they give up any role and the
+// co-host spot, their lotos are released and their queued claims dropped,
+// and both are returned. The name is free for someone else afterwards.
+// The caller must hold Mu.
+func (rm *Room) Quit(user string) (lotos []int, claims []BingoItem) {
+	rm.Leave(user)
+	delete(rm.Roles, user)
+	delete(rm.ModGrants, user)
+	if rm.CoHost == user {
+		rm.CoHost = ""
+	}
+	return rm.release(user)
+}
+
+// Kick makes user Quit and voids their current session. The caller must
+// hold Mu.
+func (rm *Room) Kick(user string) (lotos []int, claims []BingoItem) {
+	lotos, claims = rm.Quit(user)
+
+	if rm.Kicked == nil {
+		rm.Kicked = map[string]int64{}
+	}
+	rm.Kicked[user] = time.Now().UnixMilli()
+	return lotos, claims
+}
+
+// release frees user's lotos and drops their queued claims.
+func (rm *Room) release(user string) (lotos []int, claims []BingoItem) {
+	for k, v := range rm.Lotos {
+		if v == user {
+			delete(rm.Lotos, k)
+			lotos = append(lotos, k)
+		}
+	}
+
+	queue := rm.BingoQueue[:0]
+	for _, q := range rm.BingoQueue {
+		if q.User == user {
+			claims = append(claims, q)
+		} else {
+			queue = append(queue, q)
+		}
+	}
+	rm.BingoQueue = queue
+
+	// the game only waits while there are claims to review
+	if len(claims) > 0 && len(rm.BingoQueue) == 0 && !rm.BingoOK {
+		rm.Paused = false
+	}
+	return lotos, claims
+}
+
+// TakeForce cancels any pending forced number and returns its audit id.
+// The caller must hold Mu.
+func (rm *Room) TakeForce() int64 {
+	id := rm.ForceID
+	rm.NextForce, rm.ForceID = 0, 0
+	return id
+}
+
+// DiscloseForced publishes which of the called numbers were forced.
+// The caller must hold Mu.
+func (rm *Room) DiscloseForced() {
+	rm.ForcedNumbers = nil
+	for _, seq := range rm.ForcedSeqs {
+		if seq >= 1 && seq <= len(rm.Called) {
+			rm.ForcedNumbers = append(rm.ForcedNumbers, rm.Called[seq-1])
+		}
+	}
 }
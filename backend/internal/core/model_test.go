@@ -0,0 +1,52 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNameTaken(t *testing.T) {
+	rm := &Room{
+		Admin:  "ann",
+		CoHost: "cy",
+		Users:  map[string]time.Time{"bob": time.Now()},
+		Roles:  map[string]Role{"dee": RoleSpectator},
+		Lotos:  map[int]string{7: "eve"},
+	}
+	for _, user := range []string{"ann", "bob", "cy", "dee", "eve"} {
+		if !rm.NameTaken(user) {
+			t.Errorf("NameTaken(%q) = false", user)
+		}
+	}
+	if rm.NameTaken("fay") {
+		t.Error("NameTaken(\"fay\") = true")
+	}
+}
+
+func TestQuitFreesTheName(t *testing.T) {
+	rm := &Room{
+		Admin:      "ann",
+		CoHost:     "bob",
+		Users:      map[string]time.Time{"ann": time.Now(), "bob": time.Now(), "cy": time.Now()},
+		Roles:      map[string]Role{"bob": RoleModerator},
+		ModGrants:  map[string]ModGrant{"bob": {Nonce: "n"}},
+		Lotos:      map[int]string{3: "bob", 4: "cy"},
+		BingoQueue: []BingoItem{{User: "bob", Nums: "1,2"}},
+		Paused:     true,
+	}
+
+	lotos, claims := rm.Quit("bob")
+	if !reflect.DeepEqual(lotos, []int{3}) || len(claims) != 1 {
+		t.Errorf("Quit released lotos %v and claims %v", lotos, claims)
+	}
+	if rm.NameTaken("bob") {
+		t.Errorf("bob still taken: coHost %q, roles %v, grants %v", rm.CoHost, rm.Roles, rm.ModGrants)
+	}
+	if rm.Paused {
+		t.Error("game still paused with no claim left to review")
+	}
+	if !rm.NameTaken("cy") || rm.Lotos[4] != "cy" {
+		t.Error("Quit touched another player")
+	}
+}
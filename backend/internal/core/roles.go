@@ -0,0 +1,115 @@
+package core
+
+import "time"
+
+// Role is what a user may do in a room. The owner is always Room.Admin;
+// everyone else is a player unless Room.Roles says otherwise.
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleModerator Role = "moderator"
+	RolePlayer    Role = "player"
+	RoleSpectator Role = "spectator"
+)
+
+// Permission is a room action the API checks before running a handler.
+type Permission string
+
+const (
+	// PermPlay is picking lotos and claiming bingo.
+	PermPlay Permission = "play"
+	// PermRunGame is starting, timing and restarting the game.
+	PermRunGame      Permission = "run_game"
+	PermReviewBingo  Permission = "review_bingo"
+	PermPause        Permission = "pause"
+	PermModerateChat Permission = "moderate_chat"
+	// PermRemovePlayers is kicking, banning and unbanning.
+	PermRemovePlayers Permission = "remove_players"
+	// PermManageRoles is assigning roles, the co-host and the owner.
+	PermManageRoles Permission = "manage_roles"
+	// PermManageRoom is changing room settings and issuing invites.
+	PermManageRoom Permission = "manage_room"
+)
+
+// rolePermissions is the single place that says which role may do what.
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermPlay, PermRunGame, PermReviewBingo, PermPause,
+		PermModerateChat, PermRemovePlayers, PermManageRoles, PermManageRoom,
+	},
+	RoleModerator: {PermPlay, PermReviewBingo, PermPause, PermModerateChat},
+	RolePlayer:    {PermPlay},
+	RoleSpectator: {},
+}
+
+// Can reports whether the role grants p.
+func (r Role) Can(p Permission) bool {
+	for _, q := range rolePermissions[r] {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRole reads a role the owner may assign; ownership only moves by
+// transfer or hand-off.
+func ParseRole(s string) (Role, bool) {
+	switch r := Role(s); r {
+	case RoleModerator, RolePlayer, RoleSpectator:
+		return r, true
+	}
+	return "", false
+}
+
+// RoleOf returns user's role in the room. The caller must hold Mu.
+func (rm *Room) RoleOf(user string) Role {
+	if user == rm.Admin {
+		return RoleOwner
+	}
+	if r, ok := rm.Roles[user]; ok {
+		return r
+	}
+	return RolePlayer
+}
+
+// ModGrant is one moderator appointment. Like the owner's, a moderator's
+// powers need a token carrying a key derived from Nonce; ping hands that
+// token out until it is used, and only to a session issued before At
+// (unix ms).
+type ModGrant struct {
+	Nonce     string `json:"nonce"`
+	At        int64  `json:"at"`
+	Collected bool   `json:"collected"`
+}
+
+// SetRole assigns role to user. Making someone a moderator opens a new
+// grant under nonce unless they already are one; any other role drops the
+// grant. A spectator cannot play, so their lotos and queued claims are
+// released and returned. The caller must hold Mu.
+func (rm *Room) SetRole(user string, role Role, nonce string) (lotos []int, claims []BingoItem) {
+	if role == RoleModerator && rm.RoleOf(user) != RoleModerator {
+		if rm.ModGrants == nil {
+			rm.ModGrants = map[string]ModGrant{}
+		}
+		rm.ModGrants[user] = ModGrant{Nonce: nonce, At: time.Now().UnixMilli()}
+	}
+	if role != RoleModerator {
+		delete(rm.ModGrants, user)
+	}
+
+	if role == RolePlayer {
+		delete(rm.Roles, user)
+	} else {
+		if rm.Roles == nil {
+			rm.Roles = map[string]Role{}
+		}
+		rm.Roles[user] = role
+	}
+
+	if role == RoleSpectator {
+		return rm.release(user)
+	}
+	return nil, nil
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// RoomSnapshot is the persisted form of a Room. Unlike the public JSON state
+// it keeps the remaining sequence, the secret, the admin key and any pending
+// or undisclosed forced numbers.
+type RoomSnapshot struct {
+	ID            string               `json:"id"`
+	Admin         string               `json:"admin"`
+	Users         map[string]time.Time `json:"users"`
+	Numbers       []int                `json:"numbers"`
+	Called        []int                `json:"called"`
+	Current       int                  `json:"current"`
+	Interval      int                  `json:"interval"`
+	Running       bool                 `json:"running"`
+	Paused        bool                 `json:"paused"`
+	Held          bool                 `json:"held"`
+	BingoQueue    []BingoItem          `json:"bingoQueue"`
+	BingoOK       bool                 `json:"bingoOK"`
+	Winner        string               `json:"winner"`
+	WinnerNums    string               `json:"winnerNums"`
+	ApprovedAt    int64                `json:"approvedAt"`
+	GameID        int64                `json:"gameId"`
+	Lotos         map[int]string       `json:"lotos"`
+	Secret        string               `json:"secret"`
+	AdminNonce    string               `json:"adminNonce"`
+	NextForce     int                  `json:"nextForce"`
+	ForceID       int64                `json:"forceId"`
+	ForcedSeqs    []int                `json:"forcedSeqs"`
+	ForcedNumbers []int                `json:"forcedNumbers"`
+	Banned        map[string]bool      `json:"banned"`
+	JoinIPs       map[string]string    `json:"joinIps"`
+	BannedIPs     map[int64]string     `json:"ipBans"`
+	Kicked        map[string]int64     `json:"kicked"`
+	AdminPending  bool                 `json:"adminPending"`
+	HandedOffAt   int64                `json:"handedOffAt"`
+	CoHost        string               `json:"coHost"`
+	Joined        map[string]time.Time `json:"joined"`
+	Roles         map[string]Role      `json:"roles"`
+	ModGrants     map[string]ModGrant  `json:"modGrants"`
+	Visibility    Visibility           `json:"visibility"`
+	InviteGen     int                  `json:"inviteGen"`
+	InviteCodes   map[string]int64     `json:"inviteCodes"`
+	Meta          RoomMeta             `json:"meta"`
+}
+
+// Snapshot copies the room so it can be serialised outside of Mu.
+// The caller must hold Mu.
+func (rm *Room) Snapshot() RoomSnapshot {
+	users := make(map[string]time.Time, len(rm.Users))
+	for u, t := range rm.Users {
+		users[u] = t
+	}
+
+	lotos := make(map[int]string, len(rm.Lotos))
+	for k, v := range rm.Lotos {
+		lotos[k] = v
+	}
+
+	meta := rm.Meta
+	meta.Tags = slices.Clone(meta.Tags)
+
+	return RoomSnapshot{
+		ID:         rm.ID,
+		Admin:      rm.Admin,
+		Users:      users,
+		Numbers:    append([]int(nil), rm.Numbers...),
+		Called:     append([]int(nil), rm.Called...),
+		Current:    rm.Current,
+		Interval:   rm.Interval,
+		Running:    rm.Running,
+		Paused:     rm.Paused,
+		Held:       rm.Held,
+		BingoQueue: append([]BingoItem(nil), rm.BingoQueue...),
+		BingoOK:    rm.BingoOK,
+		Winner:     rm.Winner,
+		WinnerNums: rm.WinnerNums,
+		ApprovedAt: rm.ApprovedAt,
+		GameID:     rm.GameID,
+		Lotos:      lotos,
+		Secret:     rm.Secret,
+		AdminNonce: rm.AdminNonce,
+		NextForce:  rm.NextForce,
+		ForceID:    rm.ForceID,
+		ForcedSeqs: append([]int(nil), rm.ForcedSeqs...),
+
+		ForcedNumbers: append([]int(nil), rm.ForcedNumbers...),
+		Banned:        maps.Clone(rm.Banned),
+		JoinIPs:       maps.Clone(rm.JoinIPs),
+		BannedIPs:     maps.Clone(rm.BannedIPs),
+		Kicked:        maps.Clone(rm.Kicked),
+		AdminPending:  rm.AdminPending,
+		HandedOffAt:   rm.HandedOffAt,
+		CoHost:        rm.CoHost,
+		Joined:        maps.Clone(rm.Joined),
+		Roles:         maps.Clone(rm.Roles),
+		ModGrants:     maps.Clone(rm.ModGrants),
+		Visibility:    rm.Visibility,
+		InviteGen:     rm.InviteGen,
+		InviteCodes:   maps.Clone(rm.InviteCodes),
+		Meta:          meta,
+	}
+}
+
+// Restore rebuilds a live room from a snapshot.
+func (s RoomSnapshot) Restore() *Room {
+	rm := &Room{
+		ID:         s.ID,
+		Admin:      s.Admin,
+		Users:      s.Users,
+		Numbers:    s.Numbers,
+		Called:     s.Called,
+		Current:    s.Current,
+		Interval:   s.Interval,
+		Running:    s.Running,
+		Paused:     s.Paused,
+		Held:       s.Held,
+		BingoQueue: s.BingoQueue,
+		BingoOK:    s.BingoOK,
+		Winner:     s.Winner,
+		WinnerNums: s.WinnerNums,
+		ApprovedAt: s.ApprovedAt,
+		GameID:     s.GameID,
+		Lotos:      s.Lotos,
+		Secret:     s.Secret,
+		AdminNonce: s.AdminNonce,
+		NextForce:  s.NextForce,
+		ForceID:    s.ForceID,
+		ForcedSeqs: s.ForcedSeqs,
+
+		ForcedNumbers: s.ForcedNumbers,
+		Banned:        s.Banned,
+		JoinIPs:       s.JoinIPs,
+		BannedIPs:     s.BannedIPs,
+		Kicked:        s.Kicked,
+		AdminPending:  s.AdminPending,
+		HandedOffAt:   s.HandedOffAt,
+		CoHost:        s.CoHost,
+		Joined:        s.Joined,
+		Roles:         s.Roles,
+		ModGrants:     s.ModGrants,
+		Visibility:    s.Visibility,
+		InviteGen:     s.InviteGen,
+		InviteCodes:   s.InviteCodes,
+		Meta:          s.Meta,
+	}
+
+	if rm.Users == nil {
+		rm.Users = map[string]time.Time{}
+	}
+	if rm.Lotos == nil {
+		rm.Lotos = map[int]string{}
+	}
+	if rm.Interval <= 0 {
+		rm.Interval = 5
+	}
+
+	return rm
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	rm := &Room{
+		ID:         "r1",
+		Admin:      "ann",
+		AdminNonce: "nonce-1",
+		Users:      map[string]time.Time{"ann": time.Now()},
+		Lotos:      map[int]string{3: "bob"},
+		Visibility: VisibilityPublic,
+		BannedIPs:  map[int64]string{4: "198.51.100.7"},
+	}
+	rm.JoinFrom("bob", "198.51.100.8")
+	rm.SetRole("bob", RoleModerator, "mod-nonce")
+
+	data, err := json.Marshal(rm.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s RoomSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	got := s.Restore()
+	if got.AdminNonce != "nonce-1" || got.Visibility != VisibilityPublic {
+		t.Errorf("restored nonce %q, visibility %q", got.AdminNonce, got.Visibility)
+	}
+	if got.ModGrants["bob"].Nonce != "mod-nonce" || got.RoleOf("bob") != RoleModerator {
+		t.Errorf("restored grant %+v, role %q", got.ModGrants["bob"], got.RoleOf("bob"))
+	}
+	if got.Lotos[3] != "bob" {
+		t.Errorf("restored lotos %v", got.Lotos)
+	}
+	if got.JoinIPs["bob"] != "198.51.100.8" || !got.IsBanned("eve", "198.51.100.7") {
+		t.Errorf("restored join addresses %v, IP bans %v", got.JoinIPs, got.BannedIPs)
+	}
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type DailyCount struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+type UserAgentCount struct {
+	UserAgent string `json:"userAgent"`
+	Joins     int    `json:"joins"`
+}
+
+type pgAnalyticsRepo struct {
+	db *sql.DB
+}
+
+func (p *pgAnalyticsRepo) UniquePlayersPerDay(
+	ctx context.Context,
+	from, to time.Time,
+	loc *time.Location,
+) ([]DailyCount, error) {
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT (joined_at AT TIME ZONE $3::text)::date AS day,
+			COUNT(DISTINCT username)
+		FROM room_joins
+		WHERE joined_at >= $1 AND joined_at < $2
+		GROUP BY day
+		ORDER BY day
+	`, from, to, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDailyCounts(rows)
+}
+
+func (p *pgAnalyticsRepo) PeakConcurrentRoomsPerDay(
+	ctx context.Context,
+	from, to time.Time,
+	loc *time.Location,
+) ([]DailyCount, error) {
+
+	// A sweep over the moments rooms fill and empty: sorting the events
+	// keeps this O(n log n) in the joins, however long the range. A
+	// session still open lasts until now.
+	rows, err := p.db.QueryContext(ctx, `
+		WITH spans AS (
+			SELECT room_id, joined_at AS s, COALESCE(left_at, now()) AS e
+			FROM room_joins
+			WHERE joined_at < $2 AND COALESCE(left_at, now()) >= $1
+		),
+		-- merge each room's overlapping joins so a room counts once
+		marked AS (
+			SELECT room_id, s, e,
+				CASE WHEN s <= MAX(e) OVER (
+					PARTITION BY room_id ORDER BY s, e
+					ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+				) THEN 0 ELSE 1 END AS starts
+			FROM spans
+		),
+		islands AS (
+			SELECT room_id, s, e,
+				SUM(starts) OVER (
+					PARTITION BY room_id ORDER BY s, e
+					ROWS UNBOUNDED PRECEDING
+				) AS island
+			FROM marked
+		),
+		occupied AS (
+			SELECT MIN(s) AS s, MAX(e) AS e
+			FROM islands
+			GROUP BY room_id, island
+		),
+		-- +1 when a room fills, -1 when it empties, and 0 at the start
+		-- and every local midnight so each day sees the rooms already open
+		events AS (
+			SELECT s AS t, 1 AS d FROM occupied
+			UNION ALL
+			SELECT e, -1 FROM occupied
+			UNION ALL
+			SELECT $1::timestamptz, 0
+			UNION ALL
+			SELECT day AT TIME ZONE $3::text, 0
+			FROM generate_series(
+				(($1::timestamptz AT TIME ZONE $3::text)::date + 1)::timestamp,
+				($2::timestamptz AT TIME ZONE $3::text)::date::timestamp,
+				interval '1 day'
+			) AS day
+		),
+		swept AS (
+			SELECT t, SUM(d) OVER (ORDER BY t, d DESC ROWS UNBOUNDED PRECEDING) AS rooms
+			FROM events
+		)
+		SELECT (t AT TIME ZONE $3::text)::date AS day, MAX(rooms)
+		FROM swept
+		WHERE t >= $1 AND t < $2
+		GROUP BY day
+		ORDER BY day
+	`, from, to, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDailyCounts(rows)
+}
+
+func (p *pgAnalyticsRepo) AverageSessionLength(ctx context.Context, from, to time.Time) (time.Duration, error) {
+	var seconds float64
+	err := p.db.QueryRowContext(ctx, `
+		SELECT COALESCE(EXTRACT(EPOCH FROM AVG(left_at - joined_at)), 0)
+		FROM room_joins
+		WHERE left_at IS NOT NULL
+			AND joined_at >= $1 AND joined_at < $2
+	`, from, to).Scan(&seconds)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (p *pgAnalyticsRepo) TopUserAgents(
+	ctx context.Context,
+	from, to time.Time,
+	limit int,
+) ([]UserAgentCount, error) {
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT COALESCE(user_agent, '') AS ua, COUNT(*) AS joins
+		FROM room_joins
+		WHERE joined_at >= $1 AND joined_at < $2
+		GROUP BY ua
+		ORDER BY joins DESC, ua
+		LIMIT $3
+	`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []UserAgentCount
+	for rows.Next() {
+		var u UserAgentCount
+		if err := rows.Scan(&u.UserAgent, &u.Joins); err != nil {
+			return nil, err
+		}
+		res = append(res, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func scanDailyCounts(rows *sql.Rows) ([]DailyCount, error) {
+	var res []DailyCount
+	for rows.Next() {
+		var day time.Time
+		var c DailyCount
+		if err := rows.Scan(&day, &c.Count); err != nil {
+			return nil, err
+		}
+		c.Day = day.Format("2006-01-02")
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Who performed an audited action. Actor is the player's username, the
+// operator's credential name or a label for the server itself; only the
+// kind tells a player apart from an operator of the same name.
+const (
+	ActorPlayer   = "player"
+	ActorOperator = "operator"
+	ActorSystem   = "system"
+)
+
+// AuditEventRecord is one host or operator action. Before and After are
+// JSON documents, nil when the action has no such side. An empty
+// ActorKind is stored as ActorPlayer.
+type AuditEventRecord struct {
+	ID        int64
+	Action    string
+	Actor     string
+	ActorKind string
+	RoomID    string
+	Before    json.RawMessage
+	After     json.RawMessage
+	ClientIP  string
+	CreatedAt time.Time
+}
+
+// AuditFilter narrows an audit query; zero fields match everything and
+// the time range is [From, To).
+type AuditFilter struct {
+	RoomID    string
+	Actor     string
+	ActorKind string
+	Action    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+func (f AuditFilter) match(e AuditEventRecord) bool {
+	return (f.RoomID == "" || e.RoomID == f.RoomID) &&
+		(f.Actor == "" || e.Actor == f.Actor) &&
+		(f.ActorKind == "" || e.ActorKind == f.ActorKind) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		(f.From.IsZero() || !e.CreatedAt.Before(f.From)) &&
+		(f.To.IsZero() || e.CreatedAt.Before(f.To))
+}
+
+func (e AuditEventRecord) actorKind() string {
+	if e.ActorKind == "" {
+		return ActorPlayer
+	}
+	return e.ActorKind
+}
+
+type pgAuditRepo struct {
+	db *sql.DB
+}
+
+func (p *pgAuditRepo) Insert(ctx context.Context, e AuditEventRecord) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO audit_events (action, actor, actor_kind, room_id, before, after, client_ip)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, NULLIF($7, ''))
+	`, e.Action, e.Actor, e.actorKind(), e.RoomID, nullJSON(e.Before), nullJSON(e.After), e.ClientIP)
+	return err
+}
+
+func (p *pgAuditRepo) List(ctx context.Context, f AuditFilter) ([]AuditEventRecord, error) {
+	if f.Limit <= 0 {
+		f.Limit = 100
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, action, actor, actor_kind, COALESCE(room_id, ''), before, after,
+			COALESCE(client_ip, ''), created_at
+		FROM audit_events
+		WHERE ($1 = '' OR room_id = $1)
+			AND ($2 = '' OR actor = $2)
+			AND ($3 = '' OR actor_kind = $3)
+			AND ($4 = '' OR action = $4)
+			AND ($5::timestamptz IS NULL OR created_at >= $5)
+			AND ($6::timestamptz IS NULL OR created_at < $6)
+		ORDER BY created_at DESC
+		LIMIT $7
+	`, f.RoomID, f.Actor, f.ActorKind, f.Action, nullTime(f.From), nullTime(f.To), f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditEvents(rows)
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]AuditEventRecord, error) {
+	var res []AuditEventRecord
+	for rows.Next() {
+		var e AuditEventRecord
+		var before, after []byte
+		if err := rows.Scan(
+			&e.ID,
+			&e.Action,
+			&e.Actor,
+			&e.ActorKind,
+			&e.RoomID,
+			&before,
+			&after,
+			&e.ClientIP,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
+
+// nullJSON stores an empty document as SQL NULL.
+func nullJSON(b json.RawMessage) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
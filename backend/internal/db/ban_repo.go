@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// BanRecord keeps a player out of one room. ClientIP is set when the host
+// also banned the address the player last joined from.
+type BanRecord struct {
+	ID        int64
+	RoomID    string
+	Username  string
+	ClientIP  string
+	Reason    string
+	BannedBy  string
+	CreatedAt time.Time
+}
+
+type pgBanRepo struct {
+	db *sql.DB
+}
+
+func (p *pgBanRepo) Upsert(ctx context.Context, b BanRecord) (int64, error) {
+	var id int64
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO room_bans (room_id, username, client_ip, reason, banned_by)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)
+		ON CONFLICT (room_id, username) DO UPDATE
+		SET client_ip = EXCLUDED.client_ip,
+			ip_anonymized = false,
+			reason = EXCLUDED.reason,
+			banned_by = EXCLUDED.banned_by,
+			created_at = now()
+		RETURNING id
+	`, b.RoomID, b.Username, b.ClientIP, b.Reason, b.BannedBy).Scan(&id)
+	return id, err
+}
+
+func (p *pgBanRepo) Delete(ctx context.Context, roomID, username string) (*BanRecord, error) {
+	b := BanRecord{RoomID: roomID, Username: username}
+	err := p.db.QueryRowContext(ctx, `
+		DELETE FROM room_bans
+		WHERE room_id = $1 AND username = $2
+		RETURNING id, COALESCE(client_ip, ''), COALESCE(reason, ''), banned_by, created_at
+	`, roomID, username).Scan(&b.ID, &b.ClientIP, &b.Reason, &b.BannedBy, &b.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (p *pgBanRepo) ListByRoom(ctx context.Context, roomID string) ([]BanRecord, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, room_id, username, COALESCE(client_ip, ''), COALESCE(reason, ''),
+			banned_by, created_at
+		FROM room_bans
+		WHERE room_id = $1
+		ORDER BY created_at DESC
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanBans(rows)
+}
+
+func scanBans(rows *sql.Rows) ([]BanRecord, error) {
+	var res []BanRecord
+	for rows.Next() {
+		var b BanRecord
+		if err := rows.Scan(
+			&b.ID,
+			&b.RoomID,
+			&b.Username,
+			&b.ClientIP,
+			&b.Reason,
+			&b.BannedBy,
+			&b.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		res = append(res, b)
+	}
+
+	return res, rows.Err()
+}
+
+func (p *pgBanRepo) DeleteByRoom(ctx context.Context, roomID string) error {
+	_, err := p.db.ExecContext(ctx, `DELETE FROM room_bans WHERE room_id = $1`, roomID)
+	return err
+}
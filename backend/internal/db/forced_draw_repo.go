@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ForcedDrawRecord is one operator request to force the next ball.
+type ForcedDrawRecord struct {
+	ID          int64
+	RoomID      string
+	GameID      int64
+	Number      int
+	Actor       string
+	Reason      string
+	ClientIP    string
+	Status      string
+	Seq         int
+	RequestedAt time.Time
+	ResolvedAt  *time.Time
+}
+
+const (
+	ForcePending = "pending"
+	// ForceDrawn: the number was drawn as the next ball.
+	ForceDrawn = "drawn"
+	// ForceSkipped: the number had been drawn already when its turn came.
+	ForceSkipped = "skipped"
+	// ForceReplaced: a newer request took its place before the draw.
+	ForceReplaced = "replaced"
+	// ForceDropped: the game ended or restarted before the draw.
+	ForceDropped = "dropped"
+	// ForceCancelled: the room closed before the draw.
+	ForceCancelled = "cancelled"
+)
+
+type pgForcedDrawRepo struct {
+	db *sql.DB
+}
+
+func (p *pgForcedDrawRepo) Insert(ctx context.Context, f ForcedDrawRecord) (int64, error) {
+	var id int64
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO forced_draws (room_id, game_id, number, actor, reason, client_ip)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6)
+		RETURNING id
+	`, f.RoomID, f.GameID, f.Number, f.Actor, f.Reason, f.ClientIP).Scan(&id)
+
+	return id, err
+}
+
+func (p *pgForcedDrawRepo) Resolve(ctx context.Context, id int64, status string, seq int) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE forced_draws
+		SET status = $2, seq = NULLIF($3, 0), resolved_at = now()
+		WHERE id = $1 AND status = 'pending'
+	`, id, status, seq)
+	return err
+}
+
+func (p *pgForcedDrawRepo) List(ctx context.Context, roomID string, limit int) ([]ForcedDrawRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, room_id, COALESCE(game_id, 0), number, actor, reason,
+			COALESCE(client_ip, ''), status, COALESCE(seq, 0), requested_at, resolved_at
+		FROM forced_draws
+		WHERE $1 = '' OR room_id = $1
+		ORDER BY requested_at DESC
+		LIMIT $2
+	`, roomID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []ForcedDrawRecord
+	for rows.Next() {
+		var f ForcedDrawRecord
+		var resolved sql.NullTime
+		if err := rows.Scan(
+			&f.ID,
+			&f.RoomID,
+			&f.GameID,
+			&f.Number,
+			&f.Actor,
+			&f.Reason,
+			&f.ClientIP,
+			&f.Status,
+			&f.Seq,
+			&f.RequestedAt,
+			&resolved,
+		); err != nil {
+			return nil, err
+		}
+		if resolved.Valid {
+			f.ResolvedAt = &resolved.Time
+		}
+		res = append(res, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
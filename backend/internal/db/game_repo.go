@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type GameRecord struct {
+	ID         int64
+	RoomID     string
+	Admin      string
+	StartedAt  time.Time
+	EndedAt    *time.Time
+	Winner     string
+	WinnerNums string
+}
+
+type GameDrawRecord struct {
+	GameID  int64
+	Seq     int
+	Number  int
+	Forced  bool
+	DrawnAt time.Time
+}
+
+type BingoClaimRecord struct {
+	ID         int64
+	GameID     int64
+	Username   string
+	Nums       string
+	Status     string
+	ClaimedAt  time.Time
+	ResolvedAt *time.Time
+}
+
+type pgGameRepo struct {
+	db *sql.DB
+}
+
+const (
+	ClaimPending   = "pending"
+	ClaimApproved  = "approved"
+	ClaimRejected  = "rejected"
+	ClaimDismissed = "dismissed"
+)
+
+func (p *pgGameRepo) Create(ctx context.Context, roomID, admin string) (int64, error) {
+	var id int64
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO games (room_id, admin)
+		VALUES ($1, $2)
+		RETURNING id
+	`, roomID, admin).Scan(&id)
+
+	return id, err
+}
+
+func (p *pgGameRepo) Finish(ctx context.Context, gameID int64, winner, winnerNums string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE games
+		SET ended_at = now(), winner = $2, winner_nums = $3
+		WHERE id = $1
+	`, gameID, winner, winnerNums)
+	return err
+}
+
+func (p *pgGameRepo) Get(ctx context.Context, gameID int64) (*GameRecord, error) {
+	row := p.db.QueryRowContext(ctx, `
+		SELECT id, room_id, admin, started_at, ended_at,
+			COALESCE(winner, ''), COALESCE(winner_nums, '')
+		FROM games
+		WHERE id = $1
+	`, gameID)
+
+	var g GameRecord
+	var ended sql.NullTime
+	err := row.Scan(&g.ID, &g.RoomID, &g.Admin, &g.StartedAt, &ended, &g.Winner, &g.WinnerNums)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ended.Valid {
+		g.EndedAt = &ended.Time
+	}
+
+	return &g, nil
+}
+
+func (p *pgGameRepo) InsertDraw(ctx context.Context, gameID int64, seq, number int, forced bool) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO game_draws (game_id, seq, number, forced)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (game_id, seq) DO NOTHING
+	`, gameID, seq, number, forced)
+	return err
+}
+
+func (p *pgGameRepo) ListDraws(ctx context.Context, gameID int64) ([]GameDrawRecord, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT game_id, seq, number, forced, drawn_at
+		FROM game_draws
+		WHERE game_id = $1
+		ORDER BY seq
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []GameDrawRecord
+	for rows.Next() {
+		var d GameDrawRecord
+		if err := rows.Scan(&d.GameID, &d.Seq, &d.Number, &d.Forced, &d.DrawnAt); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (p *pgGameRepo) UpsertClaim(ctx context.Context, gameID int64, username, nums string) error {
+	res, err := p.db.ExecContext(ctx, `
+		UPDATE bingo_claims
+		SET nums = $3, claimed_at = now()
+		WHERE game_id = $1 AND username = $2 AND status = 'pending'
+	`, gameID, username, nums)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	_, err = p.db.ExecContext(ctx, `
+		INSERT INTO bingo_claims (game_id, username, nums)
+		VALUES ($1, $2, $3)
+	`, gameID, username, nums)
+	return err
+}
+
+func (p *pgGameRepo) ResolveClaim(ctx context.Context, gameID int64, username, status string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE bingo_claims
+		SET status = $3, resolved_at = now()
+		WHERE game_id = $1 AND username = $2 AND status = 'pending'
+	`, gameID, username, status)
+	return err
+}
+
+func (p *pgGameRepo) DismissPendingClaims(ctx context.Context, gameID int64) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE bingo_claims
+		SET status = 'dismissed', resolved_at = now()
+		WHERE game_id = $1 AND status = 'pending'
+	`, gameID)
+	return err
+}
+
+func (p *pgGameRepo) ListClaims(ctx context.Context, gameID int64) ([]BingoClaimRecord, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, game_id, username, COALESCE(nums, ''), status, claimed_at, resolved_at
+		FROM bingo_claims
+		WHERE game_id = $1
+		ORDER BY claimed_at
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []BingoClaimRecord
+	for rows.Next() {
+		var c BingoClaimRecord
+		var resolved sql.NullTime
+		if err := rows.Scan(
+			&c.ID,
+			&c.GameID,
+			&c.Username,
+			&c.Nums,
+			&c.Status,
+			&c.ClaimedAt,
+			&resolved,
+		); err != nil {
+			return nil, err
+		}
+		if resolved.Valid {
+			c.ResolvedAt = &resolved.Time
+		}
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
@@ -12,9 +13,14 @@ type RoomJoinRecord struct {
 	ClientIP  string
 	UserAgent string
 	JoinedAt  time.Time
+	LeftAt    *time.Time
 }
 
-func InsertRoomJoin(
+type pgJoinRepo struct {
+	db *sql.DB
+}
+
+func (p *pgJoinRepo) Insert(
 	ctx context.Context,
 	roomID string,
 	username string,
@@ -22,7 +28,7 @@ func InsertRoomJoin(
 	userAgent string,
 ) error {
 
-	_, err := DB.ExecContext(ctx, `
+	_, err := p.db.ExecContext(ctx, `
 		INSERT INTO room_joins (
 			room_id, username, client_ip, user_agent
 		) VALUES ($1, $2, $3, $4)
@@ -31,7 +37,7 @@ func InsertRoomJoin(
 	return err
 }
 
-func ListRoomJoinsByRoom(
+func (p *pgJoinRepo) ListByRoom(
 	ctx context.Context,
 	roomID string,
 	limit int,
@@ -41,8 +47,8 @@ func ListRoomJoinsByRoom(
 		limit = 100
 	}
 
-	rows, err := DB.QueryContext(ctx, `
-		SELECT id, room_id, username, client_ip, user_agent, joined_at
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, room_id, username, COALESCE(client_ip, ''), COALESCE(user_agent, ''), joined_at, left_at
 		FROM room_joins
 		WHERE room_id = $1
 		ORDER BY joined_at DESC
@@ -53,26 +59,10 @@ func ListRoomJoinsByRoom(
 	}
 	defer rows.Close()
 
-	var res []RoomJoinRecord
-	for rows.Next() {
-		var r RoomJoinRecord
-		if err := rows.Scan(
-			&r.ID,
-			&r.RoomID,
-			&r.Username,
-			&r.ClientIP,
-			&r.UserAgent,
-			&r.JoinedAt,
-		); err != nil {
-			return nil, err
-		}
-		res = append(res, r)
-	}
-
-	return res, nil
+	return scanRoomJoins(rows)
 }
 
-func ListRoomJoinsByUser(
+func (p *pgJoinRepo) ListByUser(
 	ctx context.Context,
 	username string,
 	limit int,
@@ -82,8 +72,8 @@ func ListRoomJoinsByUser(
 		limit = 100
 	}
 
-	rows, err := DB.QueryContext(ctx, `
-		SELECT id, room_id, username, client_ip, user_agent, joined_at
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, room_id, username, COALESCE(client_ip, ''), COALESCE(user_agent, ''), joined_at, left_at
 		FROM room_joins
 		WHERE username = $1
 		ORDER BY joined_at DESC
@@ -94,9 +84,37 @@ func ListRoomJoinsByUser(
 	}
 	defer rows.Close()
 
+	return scanRoomJoins(rows)
+}
+
+func (p *pgJoinRepo) MarkLeft(ctx context.Context, roomID, username string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE room_joins SET left_at = now()
+		WHERE room_id = $1 AND username = $2 AND left_at IS NULL
+	`, roomID, username)
+	return err
+}
+
+func (p *pgJoinRepo) MarkRoomLeft(ctx context.Context, roomID string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE room_joins SET left_at = now()
+		WHERE room_id = $1 AND left_at IS NULL
+	`, roomID)
+	return err
+}
+
+func (p *pgJoinRepo) DeleteByRoom(ctx context.Context, roomID string) error {
+	_, err := p.db.ExecContext(ctx, `
+		DELETE FROM room_joins WHERE room_id = $1
+	`, roomID)
+	return err
+}
+
+func scanRoomJoins(rows *sql.Rows) ([]RoomJoinRecord, error) {
 	var res []RoomJoinRecord
 	for rows.Next() {
 		var r RoomJoinRecord
+		var left sql.NullTime
 		if err := rows.Scan(
 			&r.ID,
 			&r.RoomID,
@@ -104,18 +122,18 @@ func ListRoomJoinsByUser(
 			&r.ClientIP,
 			&r.UserAgent,
 			&r.JoinedAt,
+			&left,
 		); err != nil {
 			return nil, err
 		}
+		if left.Valid {
+			r.LeftAt = &left.Time
+		}
 		res = append(res, r)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return res, nil
 }
-
-func DeleteJoinsByRoom(ctx context.Context, roomID string) error {
-	_, err := DB.ExecContext(ctx, `
-		DELETE FROM room_joins WHERE room_id = $1
-	`, roomID)
-	return err
-}
@@ -0,0 +1,1078 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NewMemory returns repositories that keep everything in process memory.
+// Used by --no-db dev mode; data is lost on restart.
+func NewMemory() *Repositories {
+	rooms := &memRoomRepo{rooms: map[string]RoomRecord{}}
+	joins := &memJoinRepo{anonymized: map[int64]bool{}}
+	games := &memGameRepo{games: map[int64]*GameRecord{}}
+	forced := &memForcedDrawRepo{}
+	audit := &memAuditRepo{}
+	bans := &memBanRepo{}
+
+	return &Repositories{
+		Rooms:  rooms,
+		Joins:  joins,
+		States: &memRoomStateRepo{states: map[string]RoomStateRecord{}},
+		Games:  games,
+		Forced: forced,
+		Audit:  audit,
+		Bans:   bans,
+
+		Analytics: &memAnalyticsRepo{joins: joins},
+		Retention: &memRetentionRepo{rooms: rooms, joins: joins, forced: forced, audit: audit, bans: bans},
+
+		PersonalData: &memPersonalDataRepo{rooms: rooms, joins: joins, games: games, audit: audit, bans: bans},
+	}
+}
+
+/* ===================== ROOMS ===================== */
+
+type memRoomRepo struct {
+	mu    sync.Mutex
+	rooms map[string]RoomRecord
+}
+
+func (m *memRoomRepo) Create(ctx context.Context, id, admin, secretHash, visibility string, meta RoomMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rooms[id] = RoomRecord{
+		ID:         id,
+		Admin:      admin,
+		Visibility: visibility,
+		CreatedAt:  time.Now(),
+		RoomMeta:   meta,
+	}
+	return nil
+}
+
+func (m *memRoomRepo) OpenIDs(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []string
+	for id, r := range m.rooms {
+		if r.ClosedAt == nil {
+			res = append(res, id)
+		}
+	}
+	return res, nil
+}
+
+func (m *memRoomRepo) Get(ctx context.Context, id string) (*RoomRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rooms[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &r, nil
+}
+
+func (m *memRoomRepo) List(ctx context.Context, limit int) ([]RoomRecord, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	m.mu.Lock()
+	res := make([]RoomRecord, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		res = append(res, r)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool { return res[i].CreatedAt.After(res[j].CreatedAt) })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (m *memRoomRepo) SetAdmin(ctx context.Context, id, admin string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rooms[id]
+	if !ok || r.ClosedAt != nil {
+		return nil
+	}
+	r.Admin = admin
+	m.rooms[id] = r
+	return nil
+}
+
+func (m *memRoomRepo) SetMeta(ctx context.Context, id string, meta RoomMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rooms[id]
+	if !ok || r.ClosedAt != nil {
+		return nil
+	}
+	r.RoomMeta = meta
+	m.rooms[id] = r
+	return nil
+}
+
+func (m *memRoomRepo) SetVisibility(ctx context.Context, id, visibility string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rooms[id]
+	if !ok || r.ClosedAt != nil {
+		return nil
+	}
+	r.Visibility = visibility
+	m.rooms[id] = r
+	return nil
+}
+
+func (m *memRoomRepo) Close(ctx context.Context, id, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rooms[id]
+	if !ok || r.ClosedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	r.ClosedAt = &now
+	r.CloseReason = reason
+	m.rooms[id] = r
+	return nil
+}
+
+func (m *memRoomRepo) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.rooms, id)
+	return nil
+}
+
+/* ===================== JOINS ===================== */
+
+type memJoinRepo struct {
+	mu     sync.Mutex
+	nextID int64
+	joins  []RoomJoinRecord
+	// anonymized holds the ids of joins whose IP was already masked.
+	anonymized map[int64]bool
+}
+
+func (m *memJoinRepo) Insert(ctx context.Context, roomID, username, clientIP, userAgent string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	m.joins = append(m.joins, RoomJoinRecord{
+		ID:        m.nextID,
+		RoomID:    roomID,
+		Username:  username,
+		ClientIP:  clientIP,
+		UserAgent: userAgent,
+		JoinedAt:  time.Now(),
+	})
+	return nil
+}
+
+// latest returns up to limit joins matching keep, newest first.
+func (m *memJoinRepo) latest(limit int, keep func(RoomJoinRecord) bool) []RoomJoinRecord {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []RoomJoinRecord
+	for i := len(m.joins) - 1; i >= 0 && len(res) < limit; i-- {
+		if keep(m.joins[i]) {
+			res = append(res, m.joins[i])
+		}
+	}
+	return res
+}
+
+func (m *memJoinRepo) ListByRoom(ctx context.Context, roomID string, limit int) ([]RoomJoinRecord, error) {
+	return m.latest(limit, func(j RoomJoinRecord) bool { return j.RoomID == roomID }), nil
+}
+
+func (m *memJoinRepo) ListByUser(ctx context.Context, username string, limit int) ([]RoomJoinRecord, error) {
+	return m.latest(limit, func(j RoomJoinRecord) bool { return j.Username == username }), nil
+}
+
+// markLeft closes open sessions in the room matching user ("" matches
+// everyone).
+func (m *memJoinRepo) markLeft(roomID, username string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for i, j := range m.joins {
+		if j.RoomID != roomID || j.LeftAt != nil {
+			continue
+		}
+		if username != "" && j.Username != username {
+			continue
+		}
+		m.joins[i].LeftAt = &now
+	}
+}
+
+func (m *memJoinRepo) MarkLeft(ctx context.Context, roomID, username string) error {
+	m.markLeft(roomID, username)
+	return nil
+}
+
+func (m *memJoinRepo) MarkRoomLeft(ctx context.Context, roomID string) error {
+	m.markLeft(roomID, "")
+	return nil
+}
+
+func (m *memJoinRepo) DeleteByRoom(ctx context.Context, roomID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := m.joins[:0]
+	for _, j := range m.joins {
+		if j.RoomID != roomID {
+			out = append(out, j)
+		}
+	}
+	m.joins = out
+	return nil
+}
+
+/* ===================== ROOM STATES ===================== */
+
+type memRoomStateRepo struct {
+	mu     sync.Mutex
+	states map[string]RoomStateRecord
+}
+
+func (m *memRoomStateRepo) Save(ctx context.Context, roomID string, state []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.states[roomID] = RoomStateRecord{
+		RoomID:    roomID,
+		State:     append([]byte(nil), state...),
+		UpdatedAt: time.Now(),
+	}
+	return nil
+}
+
+func (m *memRoomStateRepo) List(ctx context.Context) ([]RoomStateRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make([]RoomStateRecord, 0, len(m.states))
+	for _, s := range m.states {
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func (m *memRoomStateRepo) Delete(ctx context.Context, roomIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range roomIDs {
+		delete(m.states, id)
+	}
+	return nil
+}
+
+/* ===================== GAMES ===================== */
+
+type memGameRepo struct {
+	mu          sync.Mutex
+	nextGameID  int64
+	nextClaimID int64
+	games       map[int64]*GameRecord
+	draws       []GameDrawRecord
+	claims      []BingoClaimRecord
+}
+
+func (m *memGameRepo) Create(ctx context.Context, roomID, admin string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextGameID++
+	m.games[m.nextGameID] = &GameRecord{
+		ID:        m.nextGameID,
+		RoomID:    roomID,
+		Admin:     admin,
+		StartedAt: time.Now(),
+	}
+	return m.nextGameID, nil
+}
+
+func (m *memGameRepo) Finish(ctx context.Context, gameID int64, winner, winnerNums string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if g := m.games[gameID]; g != nil {
+		now := time.Now()
+		g.EndedAt = &now
+		g.Winner = winner
+		g.WinnerNums = winnerNums
+	}
+	return nil
+}
+
+func (m *memGameRepo) Get(ctx context.Context, gameID int64) (*GameRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g := m.games[gameID]
+	if g == nil {
+		return nil, ErrNotFound
+	}
+	cp := *g
+	return &cp, nil
+}
+
+func (m *memGameRepo) InsertDraw(ctx context.Context, gameID int64, seq, number int, forced bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range m.draws {
+		if d.GameID == gameID && d.Seq == seq {
+			return nil
+		}
+	}
+	m.draws = append(m.draws, GameDrawRecord{
+		GameID:  gameID,
+		Seq:     seq,
+		Number:  number,
+		Forced:  forced,
+		DrawnAt: time.Now(),
+	})
+	return nil
+}
+
+func (m *memGameRepo) ListDraws(ctx context.Context, gameID int64) ([]GameDrawRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []GameDrawRecord
+	for _, d := range m.draws {
+		if d.GameID == gameID {
+			res = append(res, d)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Seq < res[j].Seq })
+	return res, nil
+}
+
+func (m *memGameRepo) UpsertClaim(ctx context.Context, gameID int64, username, nums string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, c := range m.claims {
+		if c.GameID == gameID && c.Username == username && c.Status == ClaimPending {
+			m.claims[i].Nums = nums
+			m.claims[i].ClaimedAt = time.Now()
+			return nil
+		}
+	}
+
+	m.nextClaimID++
+	m.claims = append(m.claims, BingoClaimRecord{
+		ID:        m.nextClaimID,
+		GameID:    gameID,
+		Username:  username,
+		Nums:      nums,
+		Status:    ClaimPending,
+		ClaimedAt: time.Now(),
+	})
+	return nil
+}
+
+// resolve sets status on pending claims of the game matching user
+// ("" matches everyone).
+func (m *memGameRepo) resolve(gameID int64, username, status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for i, c := range m.claims {
+		if c.GameID != gameID || c.Status != ClaimPending {
+			continue
+		}
+		if username != "" && c.Username != username {
+			continue
+		}
+		m.claims[i].Status = status
+		m.claims[i].ResolvedAt = &now
+	}
+}
+
+func (m *memGameRepo) ResolveClaim(ctx context.Context, gameID int64, username, status string) error {
+	m.resolve(gameID, username, status)
+	return nil
+}
+
+func (m *memGameRepo) DismissPendingClaims(ctx context.Context, gameID int64) error {
+	m.resolve(gameID, "", ClaimDismissed)
+	return nil
+}
+
+func (m *memGameRepo) ListClaims(ctx context.Context, gameID int64) ([]BingoClaimRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []BingoClaimRecord
+	for _, c := range m.claims {
+		if c.GameID == gameID {
+			res = append(res, c)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ClaimedAt.Before(res[j].ClaimedAt) })
+	return res, nil
+}
+
+/* ===================== FORCED DRAWS ===================== */
+
+type memForcedDrawRepo struct {
+	mu         sync.Mutex
+	nextID     int64
+	forced     []ForcedDrawRecord
+	anonymized map[int64]bool
+}
+
+func (m *memForcedDrawRepo) Insert(ctx context.Context, f ForcedDrawRecord) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	f.ID = m.nextID
+	f.Status = ForcePending
+	f.RequestedAt = time.Now()
+	m.forced = append(m.forced, f)
+	return f.ID, nil
+}
+
+func (m *memForcedDrawRepo) Resolve(ctx context.Context, id int64, status string, seq int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, f := range m.forced {
+		if f.ID == id && f.Status == ForcePending {
+			now := time.Now()
+			m.forced[i].Status = status
+			m.forced[i].Seq = seq
+			m.forced[i].ResolvedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *memForcedDrawRepo) List(ctx context.Context, roomID string, limit int) ([]ForcedDrawRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []ForcedDrawRecord
+	for i := len(m.forced) - 1; i >= 0 && len(res) < limit; i-- {
+		if roomID == "" || m.forced[i].RoomID == roomID {
+			res = append(res, m.forced[i])
+		}
+	}
+	return res, nil
+}
+
+/* ===================== AUDIT ===================== */
+
+type memAuditRepo struct {
+	mu         sync.Mutex
+	nextID     int64
+	events     []AuditEventRecord
+	anonymized map[int64]bool
+}
+
+func (m *memAuditRepo) Insert(ctx context.Context, e AuditEventRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	e.ID = m.nextID
+	e.ActorKind = e.actorKind()
+	e.CreatedAt = time.Now()
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *memAuditRepo) List(ctx context.Context, f AuditFilter) ([]AuditEventRecord, error) {
+	if f.Limit <= 0 {
+		f.Limit = 100
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []AuditEventRecord
+	for i := len(m.events) - 1; i >= 0 && len(res) < f.Limit; i-- {
+		if f.match(m.events[i]) {
+			res = append(res, m.events[i])
+		}
+	}
+	return res, nil
+}
+
+/* ===================== BANS ===================== */
+
+type memBanRepo struct {
+	mu         sync.Mutex
+	nextID     int64
+	bans       []BanRecord
+	anonymized map[int64]bool
+}
+
+func (m *memBanRepo) Upsert(ctx context.Context, b BanRecord) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// a renewed ban is the newest again, so it moves to the end
+	b.CreatedAt = time.Now()
+	for i, x := range m.bans {
+		if x.RoomID == b.RoomID && x.Username == b.Username {
+			b.ID = x.ID
+			m.bans = append(m.bans[:i], m.bans[i+1:]...)
+			m.bans = append(m.bans, b)
+			delete(m.anonymized, b.ID)
+			return b.ID, nil
+		}
+	}
+
+	m.nextID++
+	b.ID = m.nextID
+	m.bans = append(m.bans, b)
+	return b.ID, nil
+}
+
+func (m *memBanRepo) Delete(ctx context.Context, roomID, username string) (*BanRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, b := range m.bans {
+		if b.RoomID == roomID && b.Username == username {
+			m.bans = append(m.bans[:i], m.bans[i+1:]...)
+			return &b, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memBanRepo) ListByRoom(ctx context.Context, roomID string) ([]BanRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []BanRecord
+	for i := len(m.bans) - 1; i >= 0; i-- {
+		if m.bans[i].RoomID == roomID {
+			res = append(res, m.bans[i])
+		}
+	}
+	return res, nil
+}
+
+func (m *memBanRepo) DeleteByRoom(ctx context.Context, roomID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.bans[:0]
+	for _, b := range m.bans {
+		if b.RoomID != roomID {
+			kept = append(kept, b)
+		}
+	}
+	m.bans = kept
+	return nil
+}
+
+/* ===================== ANALYTICS ===================== */
+
+type memAnalyticsRepo struct {
+	joins *memJoinRepo
+}
+
+// between copies the joins made in [from, to).
+func (m *memAnalyticsRepo) between(from, to time.Time) []RoomJoinRecord {
+	m.joins.mu.Lock()
+	defer m.joins.mu.Unlock()
+
+	var res []RoomJoinRecord
+	for _, j := range m.joins.joins {
+		if !j.JoinedAt.Before(from) && j.JoinedAt.Before(to) {
+			res = append(res, j)
+		}
+	}
+	return res
+}
+
+func sortedDailyCounts(byDay map[string]int) []DailyCount {
+	res := make([]DailyCount, 0, len(byDay))
+	for day, n := range byDay {
+		res = append(res, DailyCount{Day: day, Count: n})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Day < res[j].Day })
+	return res
+}
+
+func (m *memAnalyticsRepo) UniquePlayersPerDay(
+	ctx context.Context,
+	from, to time.Time,
+	loc *time.Location,
+) ([]DailyCount, error) {
+
+	seen := map[string]map[string]bool{}
+	for _, j := range m.between(from, to) {
+		day := j.JoinedAt.In(loc).Format("2006-01-02")
+		if seen[day] == nil {
+			seen[day] = map[string]bool{}
+		}
+		seen[day][j.Username] = true
+	}
+
+	byDay := map[string]int{}
+	for day, users := range seen {
+		byDay[day] = len(users)
+	}
+	return sortedDailyCounts(byDay), nil
+}
+
+func (m *memAnalyticsRepo) PeakConcurrentRoomsPerDay(
+	ctx context.Context,
+	from, to time.Time,
+	loc *time.Location,
+) ([]DailyCount, error) {
+
+	type span struct{ s, e time.Time }
+	byRoom := map[string][]span{}
+	now := time.Now()
+	for _, j := range m.between(time.Time{}, to) {
+		// a session still open lasts until now
+		e := now
+		if j.LeftAt != nil {
+			e = *j.LeftAt
+		}
+		if !e.Before(from) {
+			byRoom[j.RoomID] = append(byRoom[j.RoomID], span{j.JoinedAt, e})
+		}
+	}
+
+	type event struct {
+		t time.Time
+		d int
+	}
+	var events []event
+
+	// merge each room's overlapping joins so a room counts once
+	for _, spans := range byRoom {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].s.Before(spans[j].s) })
+		cur := spans[0]
+		for _, sp := range spans[1:] {
+			if !sp.s.After(cur.e) {
+				if sp.e.After(cur.e) {
+					cur.e = sp.e
+				}
+				continue
+			}
+			events = append(events, event{cur.s, 1}, event{cur.e, -1})
+			cur = sp
+		}
+		events = append(events, event{cur.s, 1}, event{cur.e, -1})
+	}
+
+	// 0 at the start and every local midnight so each day sees the rooms
+	// already open
+	events = append(events, event{from, 0})
+	y, mo, d := from.In(loc).Date()
+	for day := time.Date(y, mo, d+1, 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		events = append(events, event{day, 0})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].t.Equal(events[j].t) {
+			return events[i].t.Before(events[j].t)
+		}
+		return events[i].d > events[j].d
+	})
+
+	byDay := map[string]int{}
+	rooms := 0
+	for _, ev := range events {
+		rooms += ev.d
+		if ev.t.Before(from) || !ev.t.Before(to) {
+			continue
+		}
+		day := ev.t.In(loc).Format("2006-01-02")
+		if n, ok := byDay[day]; !ok || rooms > n {
+			byDay[day] = rooms
+		}
+	}
+	return sortedDailyCounts(byDay), nil
+}
+
+func (m *memAnalyticsRepo) AverageSessionLength(ctx context.Context, from, to time.Time) (time.Duration, error) {
+	var total time.Duration
+	var n int
+	for _, j := range m.between(from, to) {
+		if j.LeftAt == nil {
+			continue
+		}
+		total += j.LeftAt.Sub(j.JoinedAt)
+		n++
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+	return total / time.Duration(n), nil
+}
+
+func (m *memAnalyticsRepo) TopUserAgents(
+	ctx context.Context,
+	from, to time.Time,
+	limit int,
+) ([]UserAgentCount, error) {
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	counts := map[string]int{}
+	for _, j := range m.between(from, to) {
+		counts[j.UserAgent]++
+	}
+
+	res := make([]UserAgentCount, 0, len(counts))
+	for ua, n := range counts {
+		res = append(res, UserAgentCount{UserAgent: ua, Joins: n})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Joins != res[j].Joins {
+			return res[i].Joins > res[j].Joins
+		}
+		return res[i].UserAgent < res[j].UserAgent
+	})
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+/* ===================== RETENTION ===================== */
+
+type memRetentionRepo struct {
+	rooms  *memRoomRepo
+	joins  *memJoinRepo
+	forced *memForcedDrawRepo
+	audit  *memAuditRepo
+	bans   *memBanRepo
+}
+
+type memIPRow struct {
+	id int64
+	at time.Time
+	ip *string
+}
+
+// lockIPRows locks table and returns its rows, pointing into the repo, with
+// the ids already anonymised. The caller must call unlock.
+func (m *memRetentionRepo) lockIPRows(table IPTable) (rows []memIPRow, anonymized map[int64]bool, unlock func()) {
+	switch table {
+	case JoinIPs:
+		m.joins.mu.Lock()
+		for i := range m.joins.joins {
+			j := &m.joins.joins[i]
+			rows = append(rows, memIPRow{j.ID, j.JoinedAt, &j.ClientIP})
+		}
+		return rows, m.joins.anonymized, m.joins.mu.Unlock
+
+	case ForcedDrawIPs:
+		m.forced.mu.Lock()
+		if m.forced.anonymized == nil {
+			m.forced.anonymized = map[int64]bool{}
+		}
+		for i := range m.forced.forced {
+			f := &m.forced.forced[i]
+			rows = append(rows, memIPRow{f.ID, f.RequestedAt, &f.ClientIP})
+		}
+		return rows, m.forced.anonymized, m.forced.mu.Unlock
+
+	case AuditIPs:
+		m.audit.mu.Lock()
+		if m.audit.anonymized == nil {
+			m.audit.anonymized = map[int64]bool{}
+		}
+		for i := range m.audit.events {
+			e := &m.audit.events[i]
+			rows = append(rows, memIPRow{e.ID, e.CreatedAt, &e.ClientIP})
+		}
+		return rows, m.audit.anonymized, m.audit.mu.Unlock
+
+	case BanIPs:
+		// bans of open rooms are still enforced by address
+		open := map[string]bool{}
+		m.rooms.mu.Lock()
+		for id, r := range m.rooms.rooms {
+			open[id] = r.ClosedAt == nil
+		}
+		m.rooms.mu.Unlock()
+
+		m.bans.mu.Lock()
+		if m.bans.anonymized == nil {
+			m.bans.anonymized = map[int64]bool{}
+		}
+		for i := range m.bans.bans {
+			b := &m.bans.bans[i]
+			if !open[b.RoomID] {
+				rows = append(rows, memIPRow{b.ID, b.CreatedAt, &b.ClientIP})
+			}
+		}
+		return rows, m.bans.anonymized, m.bans.mu.Unlock
+	}
+
+	return nil, nil, func() {}
+}
+
+func (m *memRetentionRepo) fullIP(table IPTable, before time.Time, limit int) ([]IPRecord, error) {
+	rows, anonymized, unlock := m.lockIPRows(table)
+	defer unlock()
+	if anonymized == nil {
+		return nil, fmt.Errorf("unknown ip table %q", table)
+	}
+
+	var res []IPRecord
+	for _, r := range rows {
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+		if r.at.Before(before) && !anonymized[r.id] {
+			res = append(res, IPRecord{ID: r.id, ClientIP: *r.ip})
+		}
+	}
+	return res, nil
+}
+
+func (m *memRetentionRepo) RowsWithFullIP(ctx context.Context, table IPTable, before time.Time, limit int) ([]IPRecord, error) {
+	return m.fullIP(table, before, limit)
+}
+
+func (m *memRetentionRepo) CountRowsWithFullIP(ctx context.Context, table IPTable, before time.Time) (int64, error) {
+	res, err := m.fullIP(table, before, 0)
+	return int64(len(res)), err
+}
+
+func (m *memRetentionRepo) AnonymizeIP(ctx context.Context, table IPTable, id int64, ip string) error {
+	rows, anonymized, unlock := m.lockIPRows(table)
+	defer unlock()
+	if anonymized == nil {
+		return fmt.Errorf("unknown ip table %q", table)
+	}
+
+	for _, r := range rows {
+		if r.id == id {
+			*r.ip = ip
+			anonymized[id] = true
+		}
+	}
+	return nil
+}
+
+func (m *memRetentionRepo) DeleteJoinsBefore(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	m.joins.mu.Lock()
+	defer m.joins.mu.Unlock()
+
+	var n int64
+	out := m.joins.joins[:0]
+	for _, j := range m.joins.joins {
+		if j.JoinedAt.Before(before) {
+			n++
+			if !dryRun {
+				delete(m.joins.anonymized, j.ID)
+				continue
+			}
+		}
+		out = append(out, j)
+	}
+	m.joins.joins = out
+	return n, nil
+}
+
+func (m *memRetentionRepo) DeleteClosedRoomsBefore(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	m.rooms.mu.Lock()
+	defer m.rooms.mu.Unlock()
+
+	var n int64
+	for id, r := range m.rooms.rooms {
+		if r.ClosedAt != nil && r.ClosedAt.Before(before) {
+			n++
+			if !dryRun {
+				delete(m.rooms.rooms, id)
+			}
+		}
+	}
+	return n, nil
+}
+
+/* ===================== PERSONAL DATA ===================== */
+
+type memPersonalDataRepo struct {
+	rooms *memRoomRepo
+	joins *memJoinRepo
+	games *memGameRepo
+	audit *memAuditRepo
+	bans  *memBanRepo
+}
+
+func (m *memPersonalDataRepo) Export(ctx context.Context, username string) (*PersonalData, error) {
+	var data PersonalData
+
+	m.rooms.mu.Lock()
+	for _, r := range m.rooms.rooms {
+		if r.Admin == username {
+			data.Rooms = append(data.Rooms, r)
+		}
+	}
+	m.rooms.mu.Unlock()
+
+	m.joins.mu.Lock()
+	for _, j := range m.joins.joins {
+		if j.Username == username {
+			data.Joins = append(data.Joins, j)
+		}
+	}
+	m.joins.mu.Unlock()
+
+	m.games.mu.Lock()
+	for _, g := range m.games.games {
+		if g.Admin == username || g.Winner == username {
+			data.Games = append(data.Games, *g)
+		}
+	}
+	for _, c := range m.games.claims {
+		if c.Username == username {
+			data.Claims = append(data.Claims, c)
+		}
+	}
+	m.games.mu.Unlock()
+
+	m.audit.mu.Lock()
+	for _, e := range m.audit.events {
+		if concerns(e, username) {
+			data.AuditEvents = append(data.AuditEvents, e)
+		}
+	}
+	m.audit.mu.Unlock()
+	data.AuditEvents = subjectAudit(data.AuditEvents, username)
+
+	m.bans.mu.Lock()
+	for _, b := range m.bans.bans {
+		if b.Username == username || b.BannedBy == username {
+			data.Bans = append(data.Bans, b)
+		}
+	}
+	m.bans.mu.Unlock()
+	data.Bans = subjectBans(data.Bans, username)
+
+	return &data, nil
+}
+
+func (m *memPersonalDataRepo) Erase(ctx context.Context, username, pseudonym string) (ErasureCounts, error) {
+	var counts ErasureCounts
+
+	m.rooms.mu.Lock()
+	for id, r := range m.rooms.rooms {
+		if r.Admin == username {
+			r.Admin = pseudonym
+			m.rooms.rooms[id] = r
+			counts.Rooms++
+		}
+	}
+	m.rooms.mu.Unlock()
+
+	m.joins.mu.Lock()
+	for i, j := range m.joins.joins {
+		if j.Username == username {
+			m.joins.joins[i].Username = pseudonym
+			m.joins.joins[i].ClientIP = ""
+			m.joins.joins[i].UserAgent = ""
+			m.joins.anonymized[j.ID] = true
+			counts.Joins++
+		}
+	}
+	m.joins.mu.Unlock()
+
+	m.games.mu.Lock()
+	for _, g := range m.games.games {
+		touched := false
+		if g.Admin == username {
+			g.Admin = pseudonym
+			touched = true
+		}
+		if g.Winner == username {
+			g.Winner = pseudonym
+			touched = true
+		}
+		if touched {
+			counts.Games++
+		}
+	}
+	for i, c := range m.games.claims {
+		if c.Username == username {
+			m.games.claims[i].Username = pseudonym
+			counts.Claims++
+		}
+	}
+	m.games.mu.Unlock()
+
+	m.audit.mu.Lock()
+	defer m.audit.mu.Unlock()
+	for i, e := range m.audit.events {
+		if !concerns(e, username) {
+			continue
+		}
+		e, err := eraseAudit(e, username, pseudonym)
+		if err != nil {
+			return counts, err
+		}
+		m.audit.events[i] = e
+		counts.AuditEvents++
+	}
+
+	m.bans.mu.Lock()
+	defer m.bans.mu.Unlock()
+	for i, b := range m.bans.bans {
+		if b.Username != username && b.BannedBy != username {
+			continue
+		}
+		if b.Username == username {
+			m.bans.bans[i].Username = pseudonym
+			m.bans.bans[i].ClientIP = ""
+		}
+		if b.BannedBy == username {
+			m.bans.bans[i].BannedBy = pseudonym
+		}
+		counts.Bans++
+	}
+
+	return counts, nil
+}
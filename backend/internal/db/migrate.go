@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+
+	"my-source/loto-full/shared/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// migrations are the loto API's; the chat service keeps its own set in
+// the same database.
+var migrations = migrate.Set{
+	FS:     migrationFS,
+	Dir:    "migrations",
+	Table:  "schema_migrations",
+	LockID: 7243001,
+}
+
+type Migration = migrate.Migration
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer
+// build than this one.
+type ErrSchemaTooNew = migrate.ErrSchemaTooNew
+
+// Migrations returns the embedded migrations ordered by version. Files are
+// named NNNN_name.up.sql / NNNN_name.down.sql.
+func Migrations() ([]Migration, error) {
+	return migrations.Migrations()
+}
+
+func LatestVersion() (int, error) {
+	return migrations.LatestVersion()
+}
+
+// SchemaVersion reports the highest applied migration, 0 for a fresh database.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	return migrations.SchemaVersion(ctx, db)
+}
+
+// Migrate applies every pending migration in order, each in its own
+// transaction. It refuses to touch a schema newer than this build.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return migrations.Migrate(ctx, db)
+}
+
+// Rollback reverts the last steps applied migrations.
+func Rollback(ctx context.Context, db *sql.DB, steps int) error {
+	return migrations.Rollback(ctx, db, steps)
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS room_joins;
+DROP TABLE IF EXISTS rooms;
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS rooms (
+	id TEXT PRIMARY KEY,
+	admin TEXT NOT NULL,
+	secret TEXT,
+	created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rooms_admin
+	ON rooms (admin);
+
+CREATE INDEX IF NOT EXISTS idx_rooms_created_at
+	ON rooms (created_at);
+
+CREATE TABLE IF NOT EXISTS room_joins (
+	id SERIAL PRIMARY KEY,
+	room_id TEXT NOT NULL,
+	username TEXT NOT NULL,
+	client_ip TEXT,
+	user_agent TEXT,
+	joined_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_joins_room
+	ON room_joins (room_id);
+
+CREATE INDEX IF NOT EXISTS idx_room_joins_user
+	ON room_joins (username);
+
+CREATE INDEX IF NOT EXISTS idx_room_joins_joined_at
+	ON room_joins (joined_at);
+
+CREATE INDEX IF NOT EXISTS idx_room_joins_room_time
+	ON room_joins (room_id, joined_at DESC);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS room_states (
+	room_id TEXT PRIMARY KEY,
+	state JSONB NOT NULL,
+	updated_at TIMESTAMPTZ DEFAULT now()
+);
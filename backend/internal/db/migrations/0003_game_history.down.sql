@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS bingo_claims;
+DROP TABLE IF EXISTS game_draws;
+DROP TABLE IF EXISTS games;
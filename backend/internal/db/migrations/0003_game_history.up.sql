@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS games (
+	id BIGSERIAL PRIMARY KEY,
+	room_id TEXT NOT NULL,
+	admin TEXT NOT NULL,
+	started_at TIMESTAMPTZ DEFAULT now(),
+	ended_at TIMESTAMPTZ,
+	winner TEXT,
+	winner_nums TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_games_room
+	ON games (room_id, started_at DESC);
+
+CREATE TABLE IF NOT EXISTS game_draws (
+	game_id BIGINT NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+	seq INT NOT NULL,
+	number INT NOT NULL,
+	drawn_at TIMESTAMPTZ DEFAULT now(),
+	PRIMARY KEY (game_id, seq)
+);
+
+CREATE TABLE IF NOT EXISTS bingo_claims (
+	id BIGSERIAL PRIMARY KEY,
+	game_id BIGINT NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+	username TEXT NOT NULL,
+	nums TEXT,
+	status TEXT NOT NULL DEFAULT 'pending',
+	claimed_at TIMESTAMPTZ DEFAULT now(),
+	resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_bingo_claims_game
+	ON bingo_claims (game_id, claimed_at);
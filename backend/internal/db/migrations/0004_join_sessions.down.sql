@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_room_joins_open;
+
+ALTER TABLE room_joins DROP COLUMN IF EXISTS left_at;
@@ -0,0 +1,5 @@
+ALTER TABLE room_joins ADD COLUMN IF NOT EXISTS left_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_room_joins_open
+	ON room_joins (room_id, username)
+	WHERE left_at IS NULL;
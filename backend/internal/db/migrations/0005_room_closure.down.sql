@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_rooms_closed_at;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS close_reason;
+ALTER TABLE rooms DROP COLUMN IF EXISTS closed_at;
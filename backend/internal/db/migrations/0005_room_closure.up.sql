@@ -0,0 +1,5 @@
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS close_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_rooms_closed_at
+	ON rooms (closed_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_room_joins_full_ip;
+
+ALTER TABLE room_joins DROP COLUMN IF EXISTS ip_anonymized;
@@ -0,0 +1,5 @@
+ALTER TABLE room_joins ADD COLUMN IF NOT EXISTS ip_anonymized BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_room_joins_full_ip
+	ON room_joins (joined_at)
+	WHERE NOT ip_anonymized;
@@ -0,0 +1,2 @@
+-- Hashes cannot be turned back into plain text; rolling back keeps them.
+-- The pgcrypto extension is left installed as other objects may use it.
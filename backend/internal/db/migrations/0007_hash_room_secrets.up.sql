@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+-- Secrets used to be stored in plain text. crypt() with a 'bf' salt writes
+-- the same $2a$ bcrypt format the API checks against.
+UPDATE rooms
+SET secret = crypt(secret, gen_salt('bf', 10))
+WHERE secret IS NOT NULL AND secret <> '' AND secret NOT LIKE '$2_$%';
+
+-- Live snapshots carry the secret as well.
+UPDATE room_states
+SET state = jsonb_set(state, '{secret}', to_jsonb(crypt(state->>'secret', gen_salt('bf', 10))))
+WHERE COALESCE(state->>'secret', '') <> '' AND state->>'secret' NOT LIKE '$2_$%';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS forced_draws;
+ALTER TABLE game_draws DROP COLUMN IF EXISTS forced;
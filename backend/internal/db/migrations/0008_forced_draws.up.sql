@@ -0,0 +1,20 @@
+ALTER TABLE game_draws ADD COLUMN IF NOT EXISTS forced BOOLEAN NOT NULL DEFAULT false;
+
+-- Every operator request to force the next ball, kept independently of the
+-- game rows so the audit survives history cleanup.
+CREATE TABLE IF NOT EXISTS forced_draws (
+	id BIGSERIAL PRIMARY KEY,
+	room_id TEXT NOT NULL,
+	game_id BIGINT,
+	number INT NOT NULL,
+	actor TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	client_ip TEXT,
+	status TEXT NOT NULL DEFAULT 'pending',
+	seq INT,
+	requested_at TIMESTAMPTZ DEFAULT now(),
+	resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_forced_draws_room
+	ON forced_draws (room_id, requested_at DESC);
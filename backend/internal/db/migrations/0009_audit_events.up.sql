@@ -0,0 +1,21 @@
+-- Host and operator actions. before/after hold whatever state the action
+-- changed, as JSON.
+CREATE TABLE IF NOT EXISTS audit_events (
+	id BIGSERIAL PRIMARY KEY,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	room_id TEXT,
+	before JSONB,
+	after JSONB,
+	client_ip TEXT,
+	created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_room
+	ON audit_events (room_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor
+	ON audit_events (actor, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created
+	ON audit_events (created_at);
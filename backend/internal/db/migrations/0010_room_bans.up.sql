@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS room_bans (
+	id BIGSERIAL PRIMARY KEY,
+	room_id TEXT NOT NULL,
+	username TEXT NOT NULL,
+	client_ip TEXT,
+	reason TEXT,
+	banned_by TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT now(),
+	UNIQUE (room_id, username)
+);
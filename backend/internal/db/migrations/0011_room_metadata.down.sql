@@ -0,0 +1,5 @@
+ALTER TABLE rooms DROP COLUMN IF EXISTS tags;
+ALTER TABLE rooms DROP COLUMN IF EXISTS theme;
+ALTER TABLE rooms DROP COLUMN IF EXISTS language;
+ALTER TABLE rooms DROP COLUMN IF EXISTS description;
+ALTER TABLE rooms DROP COLUMN IF EXISTS title;
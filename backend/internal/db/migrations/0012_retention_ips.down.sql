@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_room_bans_full_ip;
+DROP INDEX IF EXISTS idx_audit_events_full_ip;
+DROP INDEX IF EXISTS idx_forced_draws_full_ip;
+
+ALTER TABLE room_bans DROP COLUMN IF EXISTS ip_anonymized;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS ip_anonymized;
+ALTER TABLE forced_draws DROP COLUMN IF EXISTS ip_anonymized;
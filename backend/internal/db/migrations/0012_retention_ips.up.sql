@@ -0,0 +1,17 @@
+-- Forced draws, audit events and bans record a client IP too; the
+-- retention job anonymises them like room_joins.
+ALTER TABLE forced_draws ADD COLUMN IF NOT EXISTS ip_anonymized BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS ip_anonymized BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE room_bans ADD COLUMN IF NOT EXISTS ip_anonymized BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_forced_draws_full_ip
+	ON forced_draws (requested_at)
+	WHERE NOT ip_anonymized;
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_full_ip
+	ON audit_events (created_at)
+	WHERE NOT ip_anonymized;
+
+CREATE INDEX IF NOT EXISTS idx_room_bans_full_ip
+	ON room_bans (created_at)
+	WHERE NOT ip_anonymized;
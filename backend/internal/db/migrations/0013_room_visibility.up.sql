@@ -0,0 +1,3 @@
+-- Who sees the room in the lobby and how players get in, see
+-- core.Visibility. Rooms created before this were all unlisted.
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'unlisted';
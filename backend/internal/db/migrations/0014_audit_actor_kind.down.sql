@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_audit_events_player;
+
+ALTER TABLE audit_events DROP COLUMN IF EXISTS actor_kind;
@@ -0,0 +1,10 @@
+-- Who performed an audited action: a player's session, an operator
+-- credential or the server itself, see db.ActorPlayer. actor alone cannot
+-- tell them apart, since a player may join under an operator's name.
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS actor_kind TEXT NOT NULL DEFAULT 'player';
+
+UPDATE audit_events SET actor_kind = 'operator' WHERE action = 'room.force_number';
+UPDATE audit_events SET actor_kind = 'system' WHERE action = 'room.handoff';
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_player
+	ON audit_events (actor, created_at DESC) WHERE actor_kind = 'player';
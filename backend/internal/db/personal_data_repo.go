@@ -0,0 +1,426 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// PersonalData is every row in the loto database that references a user.
+// Audit events include those the user performed as a player and those
+// whose before/after documents name the user; client IPs on rows the user
+// did not write are blanked. Operator and system actions that merely
+// share the name are not the user's.
+type PersonalData struct {
+	Rooms  []RoomRecord
+	Joins  []RoomJoinRecord
+	Games  []GameRecord
+	Claims []BingoClaimRecord
+
+	AuditEvents []AuditEventRecord
+	Bans        []BanRecord
+}
+
+// ErasureCounts reports how many rows an erasure rewrote per table.
+type ErasureCounts struct {
+	Rooms  int64 `json:"rooms"`
+	Joins  int64 `json:"joins"`
+	Games  int64 `json:"games"`
+	Claims int64 `json:"claims"`
+
+	AuditEvents int64 `json:"auditEvents"`
+	Bans        int64 `json:"bans"`
+}
+
+type pgPersonalDataRepo struct {
+	db *sql.DB
+}
+
+func (p *pgPersonalDataRepo) Export(ctx context.Context, username string) (*PersonalData, error) {
+	var data PersonalData
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, admin, visibility, created_at, closed_at, COALESCE(close_reason, ''),
+			title, description, language, theme, tags
+		FROM rooms
+		WHERE admin = $1
+		ORDER BY created_at
+	`, username)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		r, err := scanRoom(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Rooms = append(data.Rooms, *r)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = p.db.QueryContext(ctx, `
+		SELECT id, room_id, username, COALESCE(client_ip, ''), COALESCE(user_agent, ''), joined_at, left_at
+		FROM room_joins
+		WHERE username = $1
+		ORDER BY joined_at
+	`, username)
+	if err != nil {
+		return nil, err
+	}
+	data.Joins, err = scanRoomJoins(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = p.db.QueryContext(ctx, `
+		SELECT id, room_id, admin, started_at, ended_at,
+			COALESCE(winner, ''), COALESCE(winner_nums, '')
+		FROM games
+		WHERE admin = $1 OR winner = $1
+		ORDER BY started_at
+	`, username)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var g GameRecord
+		var ended sql.NullTime
+		if err := rows.Scan(&g.ID, &g.RoomID, &g.Admin, &g.StartedAt, &ended, &g.Winner, &g.WinnerNums); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if ended.Valid {
+			g.EndedAt = &ended.Time
+		}
+		data.Games = append(data.Games, g)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = p.db.QueryContext(ctx, `
+		SELECT id, game_id, username, COALESCE(nums, ''), status, claimed_at, resolved_at
+		FROM bingo_claims
+		WHERE username = $1
+		ORDER BY claimed_at
+	`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c BingoClaimRecord
+		var resolved sql.NullTime
+		if err := rows.Scan(&c.ID, &c.GameID, &c.Username, &c.Nums, &c.Status, &c.ClaimedAt, &resolved); err != nil {
+			return nil, err
+		}
+		if resolved.Valid {
+			c.ResolvedAt = &resolved.Time
+		}
+		data.Claims = append(data.Claims, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	events, err := auditMentioning(ctx, p.db, username)
+	if err != nil {
+		return nil, err
+	}
+	data.AuditEvents = subjectAudit(events, username)
+
+	rows, err = p.db.QueryContext(ctx, `
+		SELECT id, room_id, username, COALESCE(client_ip, ''), COALESCE(reason, ''),
+			banned_by, created_at
+		FROM room_bans
+		WHERE username = $1 OR banned_by = $1
+		ORDER BY created_at
+	`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans, err := scanBans(rows)
+	if err != nil {
+		return nil, err
+	}
+	data.Bans = subjectBans(bans, username)
+
+	return &data, nil
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// auditMentioning returns the audit events username performed as a player
+// or that name them in before/after.
+func auditMentioning(ctx context.Context, q querier, username string) ([]AuditEventRecord, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, action, actor, actor_kind, COALESCE(room_id, ''), before, after,
+			COALESCE(client_ip, ''), created_at
+		FROM audit_events
+		WHERE (actor = $1 AND actor_kind = 'player')
+			OR strpos(before::text, $2) > 0
+			OR strpos(after::text, $2) > 0
+		ORDER BY created_at
+	`, username, jsonString(username))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events, err := scanAuditEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// strpos only narrows the scan; keep exact matches
+	res := events[:0]
+	for _, e := range events {
+		if concerns(e, username) {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+func (p *pgPersonalDataRepo) Erase(ctx context.Context, username, pseudonym string) (ErasureCounts, error) {
+	var counts ErasureCounts
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return counts, err
+	}
+	defer tx.Rollback()
+
+	steps := []struct {
+		query string
+		count *int64
+	}{
+		{`UPDATE rooms SET admin = $2 WHERE admin = $1`, &counts.Rooms},
+		{`UPDATE room_joins
+			SET username = $2, client_ip = NULL, user_agent = NULL, ip_anonymized = true
+			WHERE username = $1`, &counts.Joins},
+		{`UPDATE games
+			SET admin = CASE WHEN admin = $1 THEN $2 ELSE admin END,
+				winner = CASE WHEN winner = $1 THEN $2 ELSE winner END
+			WHERE admin = $1 OR winner = $1`, &counts.Games},
+		{`UPDATE bingo_claims SET username = $2 WHERE username = $1`, &counts.Claims},
+		{`UPDATE room_bans
+			SET username = CASE WHEN username = $1 THEN $2 ELSE username END,
+				client_ip = CASE WHEN username = $1 THEN NULL ELSE client_ip END,
+				ip_anonymized = ip_anonymized OR username = $1,
+				banned_by = CASE WHEN banned_by = $1 THEN $2 ELSE banned_by END
+			WHERE username = $1 OR banned_by = $1`, &counts.Bans},
+	}
+
+	for _, st := range steps {
+		res, err := tx.ExecContext(ctx, st.query, username, pseudonym)
+		if err != nil {
+			return counts, err
+		}
+		*st.count, _ = res.RowsAffected()
+	}
+
+	events, err := auditMentioning(ctx, tx, username)
+	if err != nil {
+		return counts, err
+	}
+	for _, e := range events {
+		e, err := eraseAudit(e, username, pseudonym)
+		if err != nil {
+			return counts, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE audit_events
+			SET actor = $2, before = $3, after = $4, client_ip = NULLIF($5, ''),
+				ip_anonymized = ip_anonymized OR $5 = ''
+			WHERE id = $1
+		`, e.ID, e.Actor, nullJSON(e.Before), nullJSON(e.After), e.ClientIP)
+		if err != nil {
+			return counts, err
+		}
+		counts.AuditEvents++
+	}
+
+	return counts, tx.Commit()
+}
+
+// performedBy reports whether the player username performed e.
+func performedBy(e AuditEventRecord, username string) bool {
+	return e.Actor == username && e.ActorKind == ActorPlayer
+}
+
+// concerns reports whether e belongs in username's personal data.
+func concerns(e AuditEventRecord, username string) bool {
+	return performedBy(e, username) || mentions(e.Before, username) || mentions(e.After, username)
+}
+
+// eraseAudit pseudonymises username in e: as the player who performed
+// it, which also drops the client IP, and in the user fields of the
+// before/after documents.
+func eraseAudit(e AuditEventRecord, username, pseudonym string) (AuditEventRecord, error) {
+	if performedBy(e, username) {
+		e.Actor = pseudonym
+		e.ClientIP = ""
+	}
+
+	var err error
+	if e.Before, err = scrubJSON(e.Before, username, pseudonym); err != nil {
+		return e, err
+	}
+	if e.After, err = scrubJSON(e.After, username, pseudonym); err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+// subjectAudit hides the client IP of events someone else performed.
+func subjectAudit(events []AuditEventRecord, username string) []AuditEventRecord {
+	for i := range events {
+		if !performedBy(events[i], username) {
+			events[i].ClientIP = ""
+		}
+	}
+	return events
+}
+
+// subjectBans hides the banned address of bans username only issued.
+func subjectBans(bans []BanRecord, username string) []BanRecord {
+	for i := range bans {
+		if bans[i].Username != username {
+			bans[i].ClientIP = ""
+		}
+	}
+	return bans
+}
+
+// jsonString is s as it appears inside a jsonb document's text form.
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return string(bytes.TrimRight(buf.Bytes(), "\n"))
+}
+
+// userKeys are the before/after fields that hold a username or a list of
+// them, and userMapKeys the objects keyed by username. No other value in
+// an audit document is a username, even when it reads like one: a player
+// called "moderator" does not own every role change.
+var (
+	userKeys    = map[string]bool{"user": true, "users": true, "winner": true, "admin": true, "coHost": true}
+	userMapKeys = map[string]bool{"roles": true}
+)
+
+// mentions reports whether doc names username in one of its user fields,
+// at any depth.
+func mentions(doc json.RawMessage, username string) bool {
+	if len(doc) == 0 {
+		return false
+	}
+
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return false
+	}
+	_, found := replaceUser(v, username, username)
+	return found
+}
+
+// scrubJSON replaces username with pseudonym in the user fields of doc.
+func scrubJSON(doc json.RawMessage, username, pseudonym string) (json.RawMessage, error) {
+	if len(doc) == 0 {
+		return doc, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	v, found := replaceUser(v, username, pseudonym)
+	if !found {
+		return doc, nil
+	}
+	return json.Marshal(v)
+}
+
+// replaceUser walks v for user fields and replaces from with to in them.
+func replaceUser(v any, from, to string) (any, bool) {
+	switch x := v.(type) {
+	case []any:
+		found := false
+		for i := range x {
+			var f bool
+			x[i], f = replaceUser(x[i], from, to)
+			found = found || f
+		}
+		return x, found
+	case map[string]any:
+		found := false
+		for k, val := range x {
+			var f bool
+			switch {
+			case userKeys[k]:
+				x[k], f = replaceName(val, from, to)
+			case userMapKeys[k]:
+				x[k], f = renameKey(val, from, to)
+			default:
+				x[k], f = replaceUser(val, from, to)
+			}
+			found = found || f
+		}
+		return x, found
+	}
+	return v, false
+}
+
+// replaceName replaces from with to in a user field's value, a name or a
+// list of names.
+func replaceName(v any, from, to string) (any, bool) {
+	switch x := v.(type) {
+	case string:
+		if x == from {
+			return to, true
+		}
+	case []any:
+		found := false
+		for i := range x {
+			if x[i] == from {
+				x[i], found = to, true
+			}
+		}
+		return x, found
+	}
+	return v, false
+}
+
+// renameKey renames the from key of an object keyed by username.
+func renameKey(v any, from, to string) (any, bool) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v, false
+	}
+	val, ok := m[from]
+	if !ok {
+		return v, false
+	}
+	delete(m, from)
+	m[to] = val
+	return m, true
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestPersonalDataExportErase(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+
+		if err := s.Rooms.Create(ctx, "r1", "ann", "", "public", RoomMeta{Tags: []string{}}); err != nil {
+			t.Fatal(err)
+		}
+		s.Joins.Insert(ctx, "r1", "ann", "203.0.113.1", "ua-ann")
+		s.Joins.Insert(ctx, "r1", "bob", "203.0.113.2", "ua-bob")
+
+		gameID, _ := s.Games.Create(ctx, "r1", "ann")
+		s.Games.UpsertClaim(ctx, gameID, "bob", "1,2,3")
+		s.Games.Finish(ctx, gameID, "bob", "1,2,3")
+
+		s.Audit.Insert(ctx, AuditEventRecord{
+			Action: "kick", Actor: "ann", RoomID: "r1", ClientIP: "203.0.113.1",
+			Before: json.RawMessage(`{"users":["bob","cy"]}`),
+		})
+		s.Audit.Insert(ctx, AuditEventRecord{
+			Action: "role", Actor: "cy", RoomID: "r1", ClientIP: "203.0.113.3",
+			After: json.RawMessage(`{"roles":{"bob":"moderator"}}`),
+		})
+		// an operator credential that happens to share bob's name
+		s.Audit.Insert(ctx, AuditEventRecord{
+			Action: "force", Actor: "bob", ActorKind: ActorOperator, RoomID: "r1", ClientIP: "198.51.100.1",
+			After: json.RawMessage(`{"nextForce":7}`),
+		})
+		if _, err := s.Bans.Upsert(ctx, BanRecord{RoomID: "r1", Username: "bob", ClientIP: "203.0.113.2", BannedBy: "ann"}); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := s.PersonalData.Export(ctx, "bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data.Rooms) != 0 || len(data.Joins) != 1 || len(data.Games) != 1 || len(data.Claims) != 1 {
+			t.Errorf("export: %d rooms, %d joins, %d games, %d claims; want 0, 1, 1, 1",
+				len(data.Rooms), len(data.Joins), len(data.Games), len(data.Claims))
+		}
+		if len(data.AuditEvents) != 2 {
+			t.Fatalf("export: %d audit events, want the kick and the role change", len(data.AuditEvents))
+		}
+		for _, e := range data.AuditEvents {
+			if e.ActorKind != ActorPlayer || e.ClientIP != "" {
+				t.Errorf("export has %s %s's event %q from %q", e.ActorKind, e.Actor, e.Action, e.ClientIP)
+			}
+		}
+		if len(data.Bans) != 1 || data.Bans[0].ClientIP != "203.0.113.2" {
+			t.Errorf("export bans = %+v, want bob's ban with his IP", data.Bans)
+		}
+
+		// the banning host sees the ban but not the banned address
+		data, _ = s.PersonalData.Export(ctx, "ann")
+		if len(data.Rooms) != 1 || len(data.Bans) != 1 || data.Bans[0].ClientIP != "" {
+			t.Errorf("export for ann: %d rooms, bans %+v", len(data.Rooms), data.Bans)
+		}
+
+		counts, err := s.PersonalData.Erase(ctx, "bob", "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := ErasureCounts{Joins: 1, Games: 1, Claims: 1, AuditEvents: 2, Bans: 1}
+		if counts != want {
+			t.Errorf("erase counts = %+v, want %+v", counts, want)
+		}
+
+		data, _ = s.PersonalData.Export(ctx, "bob")
+		if n := len(data.Joins) + len(data.Games) + len(data.Claims) + len(data.AuditEvents) + len(data.Bans); n != 0 {
+			t.Errorf("%d records still name bob after erasure", n)
+		}
+
+		data, _ = s.PersonalData.Export(ctx, "user-1")
+		if len(data.Joins) != 1 || data.Joins[0].ClientIP != "" || data.Joins[0].UserAgent != "" {
+			t.Errorf("erased joins = %+v", data.Joins)
+		}
+		if len(data.Bans) != 1 || data.Bans[0].ClientIP != "" {
+			t.Errorf("erased bans = %+v", data.Bans)
+		}
+
+		events, _ := s.Audit.List(ctx, AuditFilter{})
+		for _, e := range events {
+			switch e.Action {
+			case "kick":
+				if !jsonEqual(t, e.Before, []byte(`{"users":["user-1","cy"]}`)) || e.ClientIP != "203.0.113.1" {
+					t.Errorf("kick event after erasure: %s from %q", e.Before, e.ClientIP)
+				}
+			case "role":
+				if !jsonEqual(t, e.After, []byte(`{"roles":{"user-1":"moderator"}}`)) {
+					t.Errorf("role event after erasure: %s", e.After)
+				}
+			case "force":
+				if e.Actor != "bob" || e.ClientIP != "198.51.100.1" {
+					t.Errorf("operator event after erasure = %+v, want it untouched", e)
+				}
+			}
+		}
+	})
+}
+
+func TestScrubJSON(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`{"user":"bob","n":1}`, `{"n":1,"user":"x"}`},
+		{`{"users":["bob","bobby"],"winner":"bob"}`, `{"users":["x","bobby"],"winner":"x"}`},
+		{`{"claim":{"user":"bob","nums":"1,2"}}`, `{"claim":{"nums":"1,2","user":"x"}}`},
+		{`{"roles":{"bob":"moderator"}}`, `{"roles":{"x":"moderator"}}`},
+		{`{"user":"bobby"}`, `{"user":"bobby"}`},
+		// only user fields hold names
+		{`{"user":"cy","role":"bob","reason":"bob"}`, `{"user":"cy","role":"bob","reason":"bob"}`},
+		{`{"bob":1}`, `{"bob":1}`},
+		{`"bob"`, `"bob"`},
+	}
+	for _, tt := range tests {
+		got, err := scrubJSON(json.RawMessage(tt.in), "bob", "x")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("scrubJSON(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
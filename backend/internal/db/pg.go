@@ -1,31 +1,54 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"os"
 
 	_ "github.com/lib/pq"
 )
 
-var DB *sql.DB
-
-func InitPostgres() {
+// Open connects to POSTGRES_DSN without touching the schema.
+func Open() (*sql.DB, error) {
 	dsn := os.Getenv("POSTGRES_DSN")
 	if dsn == "" {
-		log.Fatal("POSTGRES_DSN not set")
+		return nil, errors.New("POSTGRES_DSN not set")
 	}
 
-	var err error
-	DB, err = sql.Open("postgres", dsn)
+	conn, err := sql.Open("postgres", dsn)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	if err = DB.Ping(); err != nil {
-		log.Fatal(err)
+	if err = conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
 	}
 
+	return conn, nil
+}
+
+// InitPostgres connects and brings the schema up to date.
+func InitPostgres(ctx context.Context) (*sql.DB, error) {
+	conn, err := Open()
+	if err != nil {
+		return nil, err
+	}
 	log.Println("✅ PostgreSQL connected")
-	Init(DB)
+
+	if err := Migrate(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	version, err := SchemaVersion(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	log.Printf("✅ DB schema at version %d\n", version)
+
+	return conn, nil
 }
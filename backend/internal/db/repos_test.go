@@ -0,0 +1,575 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// store is one implementation of the repositories under test.
+type store struct {
+	*Repositories
+	// setJoinTimes rewrites when a join started and ended, so tests can
+	// lay out sessions in the past.
+	setJoinTimes func(t *testing.T, id int64, joined time.Time, left *time.Time)
+}
+
+// forEachStore runs fn against the in-memory repositories and, when
+// TEST_DATABASE_URL is set, against Postgres. Each run starts empty.
+func forEachStore(t *testing.T, fn func(t *testing.T, s store)) {
+	t.Run("memory", func(t *testing.T) {
+		repos := NewMemory()
+		joins := repos.Joins.(*memJoinRepo)
+		fn(t, store{
+			Repositories: repos,
+			setJoinTimes: func(t *testing.T, id int64, joined time.Time, left *time.Time) {
+				joins.mu.Lock()
+				defer joins.mu.Unlock()
+				for i := range joins.joins {
+					if joins.joins[i].ID == id {
+						joins.joins[i].JoinedAt = joined
+						joins.joins[i].LeftAt = left
+					}
+				}
+			},
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		conn := testPostgres(t)
+		fn(t, store{
+			Repositories: NewPostgres(conn),
+			setJoinTimes: func(t *testing.T, id int64, joined time.Time, left *time.Time) {
+				_, err := conn.Exec(`UPDATE room_joins SET joined_at = $2, left_at = $3 WHERE id = $1`, id, joined, left)
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+		})
+	})
+}
+
+// testPostgres connects to TEST_DATABASE_URL, migrates it and empties every
+// table. The database is wiped, so never point it at real data.
+func testPostgres(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	ctx := context.Background()
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.ExecContext(ctx, `
+		TRUNCATE rooms, room_joins, room_states, games, game_draws, bingo_claims,
+			forced_draws, audit_events, room_bans
+		RESTART IDENTITY CASCADE
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// jsonEqual compares documents, since Postgres does not keep the
+// formatting of JSONB columns.
+func jsonEqual(t *testing.T, got, want []byte) bool {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("%s: %v", got, err)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatalf("%s: %v", want, err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+func TestRoomRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		meta := RoomMeta{Title: "Friday", Language: "vi", Tags: []string{"family"}}
+
+		if err := s.Rooms.Create(ctx, "r1", "ann", "hash", "public", meta); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Rooms.Create(ctx, "r2", "bob", "", "private", RoomMeta{Tags: []string{}}); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := s.Rooms.Get(ctx, "r1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Admin != "ann" || r.Visibility != "public" || !reflect.DeepEqual(r.RoomMeta, meta) || r.ClosedAt != nil {
+			t.Errorf("Get = %+v", r)
+		}
+		if _, err := s.Rooms.Get(ctx, "nope"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get unknown room: %v, want ErrNotFound", err)
+		}
+
+		list, _ := s.Rooms.List(ctx, 0)
+		if len(list) != 2 || list[0].ID != "r2" || list[1].ID != "r1" {
+			t.Errorf("List = %+v, want r2 then r1", list)
+		}
+		if list, _ = s.Rooms.List(ctx, 1); len(list) != 1 {
+			t.Errorf("List(1) returned %d rooms", len(list))
+		}
+
+		s.Rooms.SetAdmin(ctx, "r1", "cy")
+		s.Rooms.SetVisibility(ctx, "r1", "unlisted")
+		s.Rooms.SetMeta(ctx, "r1", RoomMeta{Title: "Saturday", Tags: []string{"a", "b"}})
+		r, _ = s.Rooms.Get(ctx, "r1")
+		if r.Admin != "cy" || r.Visibility != "unlisted" || r.Title != "Saturday" || len(r.Tags) != 2 {
+			t.Errorf("after updates: %+v", r)
+		}
+
+		s.Rooms.Close(ctx, "r1", "idle")
+		s.Rooms.SetAdmin(ctx, "r1", "dan")
+		r, _ = s.Rooms.Get(ctx, "r1")
+		if r.ClosedAt == nil || r.CloseReason != "idle" || r.Admin != "cy" {
+			t.Errorf("closed room = %+v, want closed for idle and still cy's", r)
+		}
+		if ids, _ := s.Rooms.OpenIDs(ctx); !reflect.DeepEqual(ids, []string{"r2"}) {
+			t.Errorf("OpenIDs = %v, want [r2]", ids)
+		}
+
+		// reusing the id takes the closed row over
+		s.Rooms.Create(ctx, "r1", "eve", "", "public", RoomMeta{Tags: []string{}})
+		r, _ = s.Rooms.Get(ctx, "r1")
+		if r.Admin != "eve" || r.ClosedAt != nil || r.CloseReason != "" {
+			t.Errorf("recreated room = %+v", r)
+		}
+
+		s.Rooms.Delete(ctx, "r1")
+		if _, err := s.Rooms.Get(ctx, "r1"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get deleted room: %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestJoinRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		s.Joins.Insert(ctx, "r1", "ann", "203.0.113.1", "ua-1")
+		s.Joins.Insert(ctx, "r1", "bob", "203.0.113.2", "ua-2")
+		s.Joins.Insert(ctx, "r2", "ann", "203.0.113.1", "ua-1")
+
+		joins, err := s.Joins.ListByRoom(ctx, "r1", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(joins) != 2 || joins[0].Username != "bob" || joins[1].Username != "ann" {
+			t.Fatalf("ListByRoom = %+v, want bob then ann", joins)
+		}
+		if j := joins[1]; j.ClientIP != "203.0.113.1" || j.UserAgent != "ua-1" || j.LeftAt != nil {
+			t.Errorf("ann's join = %+v", j)
+		}
+		if joins, _ = s.Joins.ListByRoom(ctx, "r1", 1); len(joins) != 1 {
+			t.Errorf("ListByRoom(1) returned %d joins", len(joins))
+		}
+
+		joins, _ = s.Joins.ListByUser(ctx, "ann", 0)
+		if len(joins) != 2 || joins[0].RoomID != "r2" || joins[1].RoomID != "r1" {
+			t.Errorf("ListByUser = %+v, want r2 then r1", joins)
+		}
+
+		s.Joins.MarkLeft(ctx, "r1", "ann")
+		joins, _ = s.Joins.ListByRoom(ctx, "r1", 0)
+		if joins[0].LeftAt != nil || joins[1].LeftAt == nil {
+			t.Errorf("after MarkLeft: bob left %v, ann left %v", joins[0].LeftAt, joins[1].LeftAt)
+		}
+
+		s.Joins.MarkRoomLeft(ctx, "r1")
+		joins, _ = s.Joins.ListByRoom(ctx, "r1", 0)
+		if joins[0].LeftAt == nil {
+			t.Error("MarkRoomLeft left bob's session open")
+		}
+		if joins, _ = s.Joins.ListByRoom(ctx, "r2", 0); joins[0].LeftAt != nil {
+			t.Error("MarkRoomLeft closed a session in another room")
+		}
+
+		s.Joins.DeleteByRoom(ctx, "r1")
+		if joins, _ = s.Joins.ListByRoom(ctx, "r1", 0); len(joins) != 0 {
+			t.Errorf("%d joins left after DeleteByRoom", len(joins))
+		}
+	})
+}
+
+func TestRoomStateRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		s.States.Save(ctx, "r1", []byte(`{"turn":1}`))
+		s.States.Save(ctx, "r1", []byte(`{"turn":2}`))
+		s.States.Save(ctx, "r2", []byte(`{"turn":7}`))
+
+		states, err := s.States.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byRoom := map[string][]byte{}
+		for _, st := range states {
+			byRoom[st.RoomID] = st.State
+		}
+		if len(byRoom) != 2 || !jsonEqual(t, byRoom["r1"], []byte(`{"turn":2}`)) {
+			t.Errorf("List = %s", byRoom)
+		}
+
+		s.States.Delete(ctx, []string{"r1"})
+		if states, _ = s.States.List(ctx); len(states) != 1 || states[0].RoomID != "r2" {
+			t.Errorf("after Delete: %+v", states)
+		}
+	})
+}
+
+func TestGameRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		id, err := s.Games.Create(ctx, "r1", "ann")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s.Games.InsertDraw(ctx, id, 2, 40, true)
+		s.Games.InsertDraw(ctx, id, 1, 17, false)
+		// a replayed draw keeps the first number
+		s.Games.InsertDraw(ctx, id, 1, 99, false)
+		draws, err := s.Games.ListDraws(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(draws) != 2 || draws[0].Number != 17 || draws[1].Number != 40 || !draws[1].Forced {
+			t.Errorf("ListDraws = %+v", draws)
+		}
+
+		s.Games.UpsertClaim(ctx, id, "bob", "1,2")
+		s.Games.UpsertClaim(ctx, id, "cy", "3,4")
+		s.Games.UpsertClaim(ctx, id, "bob", "1,2,5")
+		s.Games.ResolveClaim(ctx, id, "bob", ClaimRejected)
+		s.Games.UpsertClaim(ctx, id, "bob", "1,2,6")
+		s.Games.ResolveClaim(ctx, id, "bob", ClaimApproved)
+		s.Games.DismissPendingClaims(ctx, id)
+
+		claims, err := s.Games.ListClaims(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		type claim struct{ user, nums, status string }
+		var got []claim
+		for _, c := range claims {
+			got = append(got, claim{c.Username, c.Nums, c.Status})
+		}
+		want := []claim{
+			{"cy", "3,4", ClaimDismissed},
+			{"bob", "1,2,5", ClaimRejected},
+			{"bob", "1,2,6", ClaimApproved},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ListClaims = %v, want %v", got, want)
+		}
+
+		s.Games.Finish(ctx, id, "bob", "1,2,6")
+		g, err := s.Games.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.RoomID != "r1" || g.Admin != "ann" || g.EndedAt == nil || g.Winner != "bob" || g.WinnerNums != "1,2,6" {
+			t.Errorf("Get = %+v", g)
+		}
+		if _, err := s.Games.Get(ctx, id+1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get unknown game: %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestForcedDrawRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		first, err := s.Forced.Insert(ctx, ForcedDrawRecord{RoomID: "r1", Number: 7, Actor: "op", Reason: "demo", ClientIP: "203.0.113.9"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _ := s.Forced.Insert(ctx, ForcedDrawRecord{RoomID: "r1", GameID: 3, Number: 8, Actor: "op"})
+		s.Forced.Insert(ctx, ForcedDrawRecord{RoomID: "r2", Number: 9, Actor: "op"})
+
+		s.Forced.Resolve(ctx, first, ForceDrawn, 4)
+		// only pending requests can be resolved
+		s.Forced.Resolve(ctx, first, ForceDropped, 0)
+
+		list, err := s.Forced.List(ctx, "r1", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 2 || list[0].ID != second || list[1].ID != first {
+			t.Fatalf("List = %+v, want the two r1 requests newest first", list)
+		}
+		if f := list[0]; f.Status != ForcePending || f.GameID != 3 || f.Seq != 0 || f.ResolvedAt != nil {
+			t.Errorf("pending request = %+v", f)
+		}
+		if f := list[1]; f.Status != ForceDrawn || f.Seq != 4 || f.ResolvedAt == nil || f.ClientIP != "203.0.113.9" || f.Reason != "demo" {
+			t.Errorf("drawn request = %+v", f)
+		}
+
+		if all, _ := s.Forced.List(ctx, "", 0); len(all) != 3 {
+			t.Errorf("List of every room returned %d requests", len(all))
+		}
+		if one, _ := s.Forced.List(ctx, "", 1); len(one) != 1 {
+			t.Errorf("List(1) returned %d requests", len(one))
+		}
+	})
+}
+
+func TestBanRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		first, err := s.Bans.Upsert(ctx, BanRecord{RoomID: "r1", Username: "bob", ClientIP: "203.0.113.2", Reason: "spam", BannedBy: "ann"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Bans.Upsert(ctx, BanRecord{RoomID: "r1", Username: "cy", BannedBy: "ann"})
+		s.Bans.Upsert(ctx, BanRecord{RoomID: "r2", Username: "bob", BannedBy: "dan"})
+		// banning again replaces the details and keeps the id
+		if again, _ := s.Bans.Upsert(ctx, BanRecord{RoomID: "r1", Username: "bob", ClientIP: "203.0.113.3", BannedBy: "eve"}); again != first {
+			t.Errorf("renewed ban has id %d, want %d", again, first)
+		}
+
+		bans, err := s.Bans.ListByRoom(ctx, "r1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bans) != 2 || bans[0].ID != first || bans[0].Username != "bob" || bans[1].Username != "cy" {
+			t.Fatalf("ListByRoom = %+v, want bob then cy", bans)
+		}
+		if b := bans[0]; b.ClientIP != "203.0.113.3" || b.Reason != "" || b.BannedBy != "eve" {
+			t.Errorf("re-banned bob = %+v", b)
+		}
+
+		b, err := s.Bans.Delete(ctx, "r1", "bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.ID != first || b.ClientIP != "203.0.113.3" {
+			t.Errorf("Delete returned %+v", b)
+		}
+		if _, err := s.Bans.Delete(ctx, "r1", "bob"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second Delete: %v, want ErrNotFound", err)
+		}
+
+		s.Bans.DeleteByRoom(ctx, "r1")
+		if bans, _ = s.Bans.ListByRoom(ctx, "r1"); len(bans) != 0 {
+			t.Errorf("%d bans left after DeleteByRoom", len(bans))
+		}
+		if bans, _ = s.Bans.ListByRoom(ctx, "r2"); len(bans) != 1 {
+			t.Errorf("DeleteByRoom dropped another room's bans: %+v", bans)
+		}
+	})
+}
+
+func TestAuditRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		start := time.Now().Add(-time.Minute)
+		s.Audit.Insert(ctx, AuditEventRecord{Action: "kick", Actor: "ann", RoomID: "r1",
+			Before: json.RawMessage(`{"users":["bob"]}`), ClientIP: "203.0.113.1"})
+		s.Audit.Insert(ctx, AuditEventRecord{Action: "role", Actor: "ann", RoomID: "r2",
+			After: json.RawMessage(`{"roles":{"cy":"moderator"}}`)})
+		s.Audit.Insert(ctx, AuditEventRecord{Action: "kick", Actor: "dan", RoomID: "r1"})
+		// an operator credential named like a player
+		s.Audit.Insert(ctx, AuditEventRecord{Action: "force", Actor: "ann", ActorKind: ActorOperator, RoomID: "r1"})
+
+		tests := []struct {
+			name string
+			f    AuditFilter
+			want []string
+		}{
+			{"all", AuditFilter{}, []string{"ann force", "dan kick", "ann role", "ann kick"}},
+			{"room", AuditFilter{RoomID: "r1"}, []string{"ann force", "dan kick", "ann kick"}},
+			{"actor", AuditFilter{Actor: "ann"}, []string{"ann force", "ann role", "ann kick"}},
+			{"player", AuditFilter{Actor: "ann", ActorKind: ActorPlayer}, []string{"ann role", "ann kick"}},
+			{"operator", AuditFilter{ActorKind: ActorOperator}, []string{"ann force"}},
+			{"action", AuditFilter{Action: "role"}, []string{"ann role"}},
+			{"limit", AuditFilter{Limit: 1}, []string{"ann force"}},
+			{"window", AuditFilter{From: start, To: start.Add(2 * time.Minute)}, []string{"ann force", "dan kick", "ann role", "ann kick"}},
+			{"before window", AuditFilter{To: start}, nil},
+		}
+		for _, tt := range tests {
+			events, err := s.Audit.List(ctx, tt.f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range events {
+				got = append(got, e.Actor+" "+e.Action)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: List = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+
+		events, _ := s.Audit.List(ctx, AuditFilter{Action: "kick", Actor: "ann"})
+		if len(events) != 1 || events[0].ActorKind != ActorPlayer || events[0].ClientIP != "203.0.113.1" ||
+			!jsonEqual(t, events[0].Before, []byte(`{"users":["bob"]}`)) {
+			t.Errorf("kick event = %+v", events)
+		}
+	})
+}
+
+func TestRetentionRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		s.Rooms.Create(ctx, "open", "ann", "", "public", RoomMeta{Tags: []string{}})
+		s.Rooms.Create(ctx, "closed", "ann", "", "public", RoomMeta{Tags: []string{}})
+		s.Rooms.Close(ctx, "closed", "idle")
+
+		s.Joins.Insert(ctx, "open", "bob", "203.0.113.2", "ua")
+		s.Joins.Insert(ctx, "open", "cy", "203.0.113.3", "ua")
+		s.Forced.Insert(ctx, ForcedDrawRecord{RoomID: "open", Number: 5, Actor: "op", ClientIP: "203.0.113.9"})
+		s.Audit.Insert(ctx, AuditEventRecord{Action: "kick", Actor: "ann", RoomID: "open", ClientIP: "203.0.113.1"})
+		// only bans of closed rooms give up their address
+		s.Bans.Upsert(ctx, BanRecord{RoomID: "open", Username: "bob", ClientIP: "203.0.113.2", BannedBy: "ann"})
+		s.Bans.Upsert(ctx, BanRecord{RoomID: "closed", Username: "cy", ClientIP: "203.0.113.3", BannedBy: "ann"})
+
+		joins, _ := s.Joins.ListByRoom(ctx, "open", 0)
+		old := time.Now().Add(-48 * time.Hour)
+		s.setJoinTimes(t, joins[1].ID, old, nil)
+		cutoff := time.Now().Add(-24 * time.Hour)
+		later := time.Now().Add(time.Minute)
+
+		tests := []struct {
+			table  IPTable
+			before time.Time
+			want   int64
+		}{
+			{JoinIPs, cutoff, 1},
+			{JoinIPs, later, 2},
+			{ForcedDrawIPs, cutoff, 0},
+			{ForcedDrawIPs, later, 1},
+			{AuditIPs, later, 1},
+			{BanIPs, later, 1},
+		}
+		for _, tt := range tests {
+			n, err := s.Retention.CountRowsWithFullIP(ctx, tt.table, tt.before)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want {
+				t.Errorf("CountRowsWithFullIP(%s) = %d, want %d", tt.table, n, tt.want)
+			}
+		}
+
+		rows, err := s.Retention.RowsWithFullIP(ctx, JoinIPs, cutoff, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 || rows[0].ID != joins[1].ID || rows[0].ClientIP != "203.0.113.2" {
+			t.Fatalf("RowsWithFullIP = %+v, want bob's old join", rows)
+		}
+		s.Retention.AnonymizeIP(ctx, JoinIPs, rows[0].ID, "203.0.113.0")
+		if n, _ := s.Retention.CountRowsWithFullIP(ctx, JoinIPs, cutoff); n != 0 {
+			t.Errorf("%d joins still have a full IP after AnonymizeIP", n)
+		}
+		if _, err := s.Retention.CountRowsWithFullIP(ctx, "rooms", later); err == nil {
+			t.Error("CountRowsWithFullIP accepted an unknown table")
+		}
+
+		if n, _ := s.Retention.DeleteJoinsBefore(ctx, cutoff, true); n != 1 {
+			t.Errorf("dry run would delete %d joins, want 1", n)
+		}
+		if n, _ := s.Retention.DeleteJoinsBefore(ctx, cutoff, false); n != 1 {
+			t.Errorf("deleted %d joins, want 1", n)
+		}
+		if joins, _ = s.Joins.ListByRoom(ctx, "open", 0); len(joins) != 1 || joins[0].Username != "cy" {
+			t.Errorf("joins after DeleteJoinsBefore = %+v", joins)
+		}
+
+		if n, _ := s.Retention.DeleteClosedRoomsBefore(ctx, later, true); n != 1 {
+			t.Errorf("dry run would delete %d rooms, want 1", n)
+		}
+		if n, _ := s.Retention.DeleteClosedRoomsBefore(ctx, later, false); n != 1 {
+			t.Errorf("deleted %d rooms, want 1", n)
+		}
+		if ids, _ := s.Rooms.OpenIDs(ctx); !reflect.DeepEqual(ids, []string{"open"}) {
+			t.Errorf("OpenIDs = %v after deleting the closed room", ids)
+		}
+	})
+}
+
+// addJoin records a session in room over [joined, left]; a nil left keeps
+// it open.
+func addJoin(t *testing.T, s store, room string, joined time.Time, left *time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	if err := s.Joins.Insert(ctx, room, "u", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	joins, err := s.Joins.ListByRoom(ctx, room, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.setJoinTimes(t, joins[0].ID, joined, left)
+}
+
+func TestPeakConcurrentRooms(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		loc := time.UTC
+		day := time.Date(2026, 3, 1, 0, 0, 0, 0, loc)
+		at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+		left := func(t time.Time) *time.Time { return &t }
+
+		// two joins in r1 overlap and count as one room
+		addJoin(t, s, "r1", at(10, 0), left(at(11, 0)))
+		addJoin(t, s, "r1", at(10, 30), left(at(12, 0)))
+		addJoin(t, s, "r2", at(11, 30), left(at(13, 0)))
+		addJoin(t, s, "r3", at(11, 45), left(at(12, 15)))
+		// open past midnight, so the next day starts with it
+		addJoin(t, s, "r4", at(23, 0), left(at(25, 0)))
+		// a room opening as another closes overlaps it for that moment
+		addJoin(t, s, "r5", at(30, 0), left(at(31, 0)))
+		addJoin(t, s, "r6", at(31, 0), left(at(32, 0)))
+
+		got, err := s.Analytics.PeakConcurrentRoomsPerDay(context.Background(), day, day.AddDate(0, 0, 3), loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []DailyCount{{"2026-03-01", 3}, {"2026-03-02", 2}, {"2026-03-03", 0}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestPeakConcurrentRoomsOpenSession(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		loc := time.UTC
+		y, m, d := time.Now().In(loc).Date()
+		today := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		from := today.AddDate(0, 0, -3)
+
+		// still in the room, so it fills every day since it joined
+		addJoin(t, s, "r1", today.AddDate(0, 0, -2).Add(time.Hour), nil)
+
+		got, err := s.Analytics.PeakConcurrentRoomsPerDay(context.Background(), from, today.AddDate(0, 0, 1), loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []DailyCount
+		for i, n := range []int{0, 1, 1, 1} {
+			want = append(want, DailyCount{from.AddDate(0, 0, i).Format("2006-01-02"), n})
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
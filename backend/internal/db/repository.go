@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Get-style lookups when no row matches.
+var ErrNotFound = errors.New("not found")
+
+type RoomRepository interface {
+	// Create inserts the room, taking over any row with the same id. The
+	// caller only creates rooms that are not live, so such a row is
+	// closed or was left open by a crash.
+	Create(ctx context.Context, id, admin, secretHash, visibility string, meta RoomMeta) error
+	// OpenIDs lists the rooms not marked closed.
+	OpenIDs(ctx context.Context) ([]string, error)
+	Get(ctx context.Context, id string) (*RoomRecord, error)
+	List(ctx context.Context, limit int) ([]RoomRecord, error)
+	// SetAdmin records that an open room changed hands.
+	SetAdmin(ctx context.Context, id, admin string) error
+	SetMeta(ctx context.Context, id string, meta RoomMeta) error
+	SetVisibility(ctx context.Context, id, visibility string) error
+	// Close marks the room as finished; the row is kept for history.
+	Close(ctx context.Context, id, reason string) error
+	Delete(ctx context.Context, id string) error
+}
+
+type JoinRepository interface {
+	Insert(ctx context.Context, roomID, username, clientIP, userAgent string) error
+	ListByRoom(ctx context.Context, roomID string, limit int) ([]RoomJoinRecord, error)
+	ListByUser(ctx context.Context, username string, limit int) ([]RoomJoinRecord, error)
+	// MarkLeft ends the user's open sessions in the room.
+	MarkLeft(ctx context.Context, roomID, username string) error
+	// MarkRoomLeft ends every open session in the room.
+	MarkRoomLeft(ctx context.Context, roomID string) error
+	DeleteByRoom(ctx context.Context, roomID string) error
+}
+
+type RoomStateRepository interface {
+	Save(ctx context.Context, roomID string, state []byte) error
+	List(ctx context.Context) ([]RoomStateRecord, error)
+	// Delete drops the snapshots of the given rooms.
+	Delete(ctx context.Context, roomIDs []string) error
+}
+
+type GameRepository interface {
+	Create(ctx context.Context, roomID, admin string) (int64, error)
+	Finish(ctx context.Context, gameID int64, winner, winnerNums string) error
+	Get(ctx context.Context, gameID int64) (*GameRecord, error)
+
+	InsertDraw(ctx context.Context, gameID int64, seq, number int, forced bool) error
+	ListDraws(ctx context.Context, gameID int64) ([]GameDrawRecord, error)
+
+	// UpsertClaim records a claim, or updates the numbers of the user's
+	// claim that is still waiting for the host.
+	UpsertClaim(ctx context.Context, gameID int64, username, nums string) error
+	ResolveClaim(ctx context.Context, gameID int64, username, status string) error
+	// DismissPendingClaims closes every claim left in the queue once a
+	// winner has been approved.
+	DismissPendingClaims(ctx context.Context, gameID int64) error
+	ListClaims(ctx context.Context, gameID int64) ([]BingoClaimRecord, error)
+}
+
+// ForcedDrawRepository is the audit trail of operator-forced balls.
+type ForcedDrawRepository interface {
+	Insert(ctx context.Context, f ForcedDrawRecord) (int64, error)
+	// Resolve closes a pending request; seq is the draw position when the
+	// number was actually drawn, otherwise 0.
+	Resolve(ctx context.Context, id int64, status string, seq int) error
+	// List returns the newest requests first; an empty roomID lists all.
+	List(ctx context.Context, roomID string, limit int) ([]ForcedDrawRecord, error)
+}
+
+// BanRepository stores per-room bans.
+type BanRepository interface {
+	// Upsert bans the user, replacing an existing ban's details, and
+	// returns the ban's id.
+	Upsert(ctx context.Context, b BanRecord) (int64, error)
+	// Delete lifts a ban and returns it, or ErrNotFound.
+	Delete(ctx context.Context, roomID, username string) (*BanRecord, error)
+	ListByRoom(ctx context.Context, roomID string) ([]BanRecord, error)
+	// DeleteByRoom drops the bans of an earlier room that had the same id.
+	DeleteByRoom(ctx context.Context, roomID string) error
+}
+
+// AuditRepository records host and operator actions.
+type AuditRepository interface {
+	Insert(ctx context.Context, e AuditEventRecord) error
+	// List returns matching events, newest first.
+	List(ctx context.Context, f AuditFilter) ([]AuditEventRecord, error)
+}
+
+// AnalyticsRepository aggregates room_joins. Days are calendar days in loc
+// and the range is [from, to).
+type AnalyticsRepository interface {
+	UniquePlayersPerDay(ctx context.Context, from, to time.Time, loc *time.Location) ([]DailyCount, error)
+	// PeakConcurrentRoomsPerDay reports, per day, the highest number of
+	// rooms with at least one player present at the same moment.
+	PeakConcurrentRoomsPerDay(ctx context.Context, from, to time.Time, loc *time.Location) ([]DailyCount, error)
+	// AverageSessionLength only counts sessions that have ended.
+	AverageSessionLength(ctx context.Context, from, to time.Time) (time.Duration, error)
+	TopUserAgents(ctx context.Context, from, to time.Time, limit int) ([]UserAgentCount, error)
+}
+
+// IPTable names a table whose rows keep the client IP of whoever wrote them.
+type IPTable string
+
+const (
+	JoinIPs       IPTable = "room_joins"
+	ForcedDrawIPs IPTable = "forced_draws"
+	AuditIPs      IPTable = "audit_events"
+	BanIPs        IPTable = "room_bans"
+)
+
+// IPTables lists every table the retention job anonymises.
+var IPTables = []IPTable{JoinIPs, ForcedDrawIPs, AuditIPs, BanIPs}
+
+type IPRecord struct {
+	ID       int64
+	ClientIP string
+}
+
+// RetentionRepository backs the data retention job. The dryRun variants
+// only count what would be removed.
+type RetentionRepository interface {
+	// RowsWithFullIP returns up to limit rows of table written before the
+	// cutoff whose client IP has not been anonymised yet.
+	RowsWithFullIP(ctx context.Context, table IPTable, before time.Time, limit int) ([]IPRecord, error)
+	CountRowsWithFullIP(ctx context.Context, table IPTable, before time.Time) (int64, error)
+	AnonymizeIP(ctx context.Context, table IPTable, id int64, ip string) error
+
+	DeleteJoinsBefore(ctx context.Context, before time.Time, dryRun bool) (int64, error)
+	DeleteClosedRoomsBefore(ctx context.Context, before time.Time, dryRun bool) (int64, error)
+}
+
+// PersonalDataRepository finds and erases everything stored about a user.
+type PersonalDataRepository interface {
+	Export(ctx context.Context, username string) (*PersonalData, error)
+	// Erase replaces the username with pseudonym everywhere and drops the
+	// user's IPs and user agents.
+	Erase(ctx context.Context, username, pseudonym string) (ErasureCounts, error)
+}
+
+// Repositories bundles every store the API depends on.
+type Repositories struct {
+	Rooms  RoomRepository
+	Joins  JoinRepository
+	States RoomStateRepository
+	Games  GameRepository
+	Forced ForcedDrawRepository
+	Audit  AuditRepository
+	Bans   BanRepository
+
+	Analytics AnalyticsRepository
+	Retention RetentionRepository
+
+	PersonalData PersonalDataRepository
+}
+
+func NewPostgres(db *sql.DB) *Repositories {
+	return &Repositories{
+		Rooms:  &pgRoomRepo{db: db},
+		Joins:  &pgJoinRepo{db: db},
+		States: &pgRoomStateRepo{db: db},
+		Games:  &pgGameRepo{db: db},
+		Forced: &pgForcedDrawRepo{db: db},
+		Audit:  &pgAuditRepo{db: db},
+		Bans:   &pgBanRepo{db: db},
+
+		Analytics: &pgAnalyticsRepo{db: db},
+		Retention: &pgRetentionRepo{db: db},
+
+		PersonalData: &pgPersonalDataRepo{db: db},
+	}
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type pgRetentionRepo struct {
+	db *sql.DB
+}
+
+type ipTable struct {
+	// at is the column saying when the row was written.
+	at string
+	// inUse, if set, matches rows whose IP is still enforced and must be
+	// kept intact: an IP ban only works while the address is exact.
+	inUse string
+}
+
+var ipTables = map[IPTable]ipTable{
+	JoinIPs:       {at: "joined_at"},
+	ForcedDrawIPs: {at: "requested_at"},
+	AuditIPs:      {at: "created_at"},
+	BanIPs: {
+		at:    "created_at",
+		inUse: "room_id IN (SELECT id FROM rooms WHERE closed_at IS NULL)",
+	},
+}
+
+// fullIPWhere selects the rows of table written before $1 whose IP can be
+// anonymised.
+func fullIPWhere(table IPTable) (ipTable, string, error) {
+	t, ok := ipTables[table]
+	if !ok {
+		return t, "", fmt.Errorf("unknown ip table %q", table)
+	}
+
+	where := t.at + " < $1 AND NOT ip_anonymized"
+	if t.inUse != "" {
+		where += " AND NOT (" + t.inUse + ")"
+	}
+	return t, where, nil
+}
+
+func (p *pgRetentionRepo) CountRowsWithFullIP(ctx context.Context, table IPTable, before time.Time) (int64, error) {
+	_, where, err := fullIPWhere(table)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err = p.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM `+string(table)+`
+		WHERE `+where+`
+	`, before).Scan(&n)
+	return n, err
+}
+
+func (p *pgRetentionRepo) RowsWithFullIP(ctx context.Context, table IPTable, before time.Time, limit int) ([]IPRecord, error) {
+	t, where, err := fullIPWhere(table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, COALESCE(client_ip, '')
+		FROM `+string(table)+`
+		WHERE `+where+`
+		ORDER BY `+t.at+`
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []IPRecord
+	for rows.Next() {
+		var r IPRecord
+		if err := rows.Scan(&r.ID, &r.ClientIP); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, rows.Err()
+}
+
+func (p *pgRetentionRepo) AnonymizeIP(ctx context.Context, table IPTable, id int64, ip string) error {
+	if _, ok := ipTables[table]; !ok {
+		return fmt.Errorf("unknown ip table %q", table)
+	}
+
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE `+string(table)+`
+		SET client_ip = $2, ip_anonymized = true
+		WHERE id = $1
+	`, id, ip)
+	return err
+}
+
+func (p *pgRetentionRepo) DeleteJoinsBefore(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		var n int64
+		err := p.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM room_joins WHERE joined_at < $1
+		`, before).Scan(&n)
+		return n, err
+	}
+
+	res, err := p.db.ExecContext(ctx, `
+		DELETE FROM room_joins WHERE joined_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (p *pgRetentionRepo) DeleteClosedRoomsBefore(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		var n int64
+		err := p.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM rooms WHERE closed_at < $1
+		`, before).Scan(&n)
+		return n, err
+	}
+
+	res, err := p.db.ExecContext(ctx, `
+		DELETE FROM rooms WHERE closed_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -2,49 +2,109 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// RoomRecord is a rooms row. The secret hash is write-only: no query
+// selects it back.
 type RoomRecord struct {
-	ID        string
-	Admin     string
-	Secret    string
-	CreatedAt time.Time
+	ID         string
+	Admin      string
+	Visibility string
+	CreatedAt  time.Time
+	RoomMeta
+
+	ClosedAt    *time.Time
+	CloseReason string
+}
+
+// RoomMeta is how a room presents itself in the lobby.
+type RoomMeta struct {
+	Title       string
+	Description string
+	Language    string
+	Theme       string
+	Tags        []string
+}
+
+type pgRoomRepo struct {
+	db *sql.DB
 }
 
-func CreateRoom(ctx context.Context, id, admin, secret string) error {
-	_, err := DB.ExecContext(ctx, `
-		INSERT INTO rooms (id, admin, secret)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (id) DO NOTHING
-	`, id, admin, secret)
+func (p *pgRoomRepo) Create(ctx context.Context, id, admin, secretHash, visibility string, meta RoomMeta) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO rooms (id, admin, secret, visibility, title, description, language, theme, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE
+		SET admin = EXCLUDED.admin,
+			secret = EXCLUDED.secret,
+			visibility = EXCLUDED.visibility,
+			title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			language = EXCLUDED.language,
+			theme = EXCLUDED.theme,
+			tags = EXCLUDED.tags,
+			created_at = now(),
+			closed_at = NULL,
+			close_reason = NULL
+	`, id, admin, secretHash, visibility,
+		meta.Title, meta.Description, meta.Language, meta.Theme, pq.Array(meta.Tags))
 
 	return err
 }
 
-func GetRoom(ctx context.Context, id string) (*RoomRecord, error) {
-	row := DB.QueryRowContext(ctx, `
-		SELECT id, admin, secret, created_at
+func (p *pgRoomRepo) OpenIDs(ctx context.Context) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id FROM rooms WHERE closed_at IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	return res, rows.Err()
+}
+
+func (p *pgRoomRepo) Get(ctx context.Context, id string) (*RoomRecord, error) {
+	row := p.db.QueryRowContext(ctx, `
+		SELECT id, admin, visibility, created_at, closed_at, COALESCE(close_reason, ''),
+			title, description, language, theme, tags
 		FROM rooms
 		WHERE id = $1
 	`, id)
 
-	var r RoomRecord
-	err := row.Scan(&r.ID, &r.Admin, &r.Secret, &r.CreatedAt)
+	r, err := scanRoom(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &r, nil
+	return r, nil
 }
 
-func ListRooms(ctx context.Context, limit int) ([]RoomRecord, error) {
+func (p *pgRoomRepo) List(ctx context.Context, limit int) ([]RoomRecord, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 
-	rows, err := DB.QueryContext(ctx, `
-		SELECT id, admin, secret, created_at
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, admin, visibility, created_at, closed_at, COALESCE(close_reason, ''),
+			title, description, language, theme, tags
 		FROM rooms
 		ORDER BY created_at DESC
 		LIMIT $1
@@ -56,19 +116,83 @@ func ListRooms(ctx context.Context, limit int) ([]RoomRecord, error) {
 
 	var res []RoomRecord
 	for rows.Next() {
-		var r RoomRecord
-		if err := rows.Scan(&r.ID, &r.Admin, &r.Secret, &r.CreatedAt); err != nil {
+		r, err := scanRoom(rows)
+		if err != nil {
 			return nil, err
 		}
-		res = append(res, r)
+		res = append(res, *r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return res, nil
 }
 
-func DeleteRoom(ctx context.Context, id string) error {
-	_, err := DB.ExecContext(ctx, `
+func (p *pgRoomRepo) SetAdmin(ctx context.Context, id, admin string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE rooms
+		SET admin = $2
+		WHERE id = $1 AND closed_at IS NULL
+	`, id, admin)
+	return err
+}
+
+func (p *pgRoomRepo) SetMeta(ctx context.Context, id string, meta RoomMeta) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE rooms
+		SET title = $2, description = $3, language = $4, theme = $5, tags = $6
+		WHERE id = $1 AND closed_at IS NULL
+	`, id, meta.Title, meta.Description, meta.Language, meta.Theme, pq.Array(meta.Tags))
+	return err
+}
+
+func (p *pgRoomRepo) SetVisibility(ctx context.Context, id, visibility string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE rooms
+		SET visibility = $2
+		WHERE id = $1 AND closed_at IS NULL
+	`, id, visibility)
+	return err
+}
+
+func (p *pgRoomRepo) Close(ctx context.Context, id, reason string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE rooms
+		SET closed_at = now(), close_reason = $2
+		WHERE id = $1 AND closed_at IS NULL
+	`, id, reason)
+	return err
+}
+
+func (p *pgRoomRepo) Delete(ctx context.Context, id string) error {
+	_, err := p.db.ExecContext(ctx, `
 		DELETE FROM rooms WHERE id = $1
 	`, id)
 	return err
 }
+
+func scanRoom(row interface{ Scan(...any) error }) (*RoomRecord, error) {
+	var r RoomRecord
+	var closed sql.NullTime
+	if err := row.Scan(
+		&r.ID,
+		&r.Admin,
+		&r.Visibility,
+		&r.CreatedAt,
+		&closed,
+		&r.CloseReason,
+		&r.Title,
+		&r.Description,
+		&r.Language,
+		&r.Theme,
+		pq.Array(&r.Tags),
+	); err != nil {
+		return nil, err
+	}
+	if closed.Valid {
+		r.ClosedAt = &closed.Time
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type RoomStateRecord struct {
+	RoomID    string
+	State     []byte
+	UpdatedAt time.Time
+}
+
+type pgRoomStateRepo struct {
+	db *sql.DB
+}
+
+func (p *pgRoomStateRepo) Save(ctx context.Context, roomID string, state []byte) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO room_states (room_id, state, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (room_id) DO UPDATE
+		SET state = EXCLUDED.state, updated_at = now()
+	`, roomID, state)
+
+	return err
+}
+
+func (p *pgRoomStateRepo) List(ctx context.Context) ([]RoomStateRecord, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT room_id, state, updated_at
+		FROM room_states
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []RoomStateRecord
+	for rows.Next() {
+		var r RoomStateRecord
+		if err := rows.Scan(&r.RoomID, &r.State, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (p *pgRoomStateRepo) Delete(ctx context.Context, roomIDs []string) error {
+	_, err := p.db.ExecContext(ctx, `
+		DELETE FROM room_states WHERE room_id = ANY($1)
+	`, pq.Array(roomIDs))
+	return err
+}
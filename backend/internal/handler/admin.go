@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"my-source/loto-full/backend/internal/utils"
+)
+
+// adminOperator is who ADMIN_SECRET acts as in the audit.
+const adminOperator = "admin"
+
+// operator is one operator credential and the name audited for it.
+type operator struct {
+	name   string
+	secret string
+}
+
+// operators are ADMIN_SECRET plus the OPERATOR_KEYS entries, set by
+// LoadSecrets.
+var operators []operator
+
+// loadOperators reads ADMIN_SECRET and OPERATOR_KEYS, a comma separated
+// list of name:secret pairs giving each operator their own credential.
+func loadOperators() ([]operator, error) {
+	var ops []operator
+	if AdminSecret != "" {
+		ops = append(ops, operator{name: adminOperator, secret: AdminSecret})
+	}
+
+	for _, entry := range strings.Split(os.Getenv("OPERATOR_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, secret, ok := strings.Cut(entry, ":")
+		name, secret = strings.TrimSpace(name), strings.TrimSpace(secret)
+		if !ok || name == "" || secret == "" {
+			return nil, fmt.Errorf("OPERATOR_KEYS: expected name:secret, got %q", entry)
+		}
+		for _, op := range ops {
+			if op.name == name || op.secret == secret {
+				return nil, fmt.Errorf("OPERATOR_KEYS: %q repeats a name or secret", name)
+			}
+		}
+		ops = append(ops, operator{name: name, secret: secret})
+	}
+
+	return ops, nil
+}
+
+type operatorKey struct{}
+
+// AdminOnly guards operator endpoints with an operator credential, sent as
+// "Authorization: Bearer <secret>".
+func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(operators) == 0 {
+			http.Error(w, "admin secret not configured", http.StatusInternalServerError)
+			return
+		}
+
+		name := operatorFor(utils.BearerToken(r))
+		if name == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), operatorKey{}, name)))
+	}
+}
+
+// operatorFor returns the name of the operator whose credential token is,
+// or "" for none.
+func operatorFor(token string) string {
+	name := ""
+	for _, op := range operators {
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(op.secret)) == 1 {
+			name = op.name
+		}
+	}
+	return name
+}
+
+// OperatorFrom returns the operator AdminOnly verified.
+func OperatorFrom(r *http.Request) string {
+	name, _ := r.Context().Value(operatorKey{}).(string)
+	return name
+}
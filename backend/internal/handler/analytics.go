@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/utils"
+)
+
+type JoinInfo struct {
+	ID        int64      `json:"id"`
+	RoomID    string     `json:"roomId"`
+	Username  string     `json:"username"`
+	ClientIP  string     `json:"clientIp"`
+	UserAgent string     `json:"userAgent"`
+	JoinedAt  time.Time  `json:"joinedAt"`
+	LeftAt    *time.Time `json:"leftAt"`
+}
+
+type AnalyticsReport struct {
+	From                time.Time           `json:"from"`
+	To                  time.Time           `json:"to"`
+	TimeZone            string              `json:"timeZone"`
+	UniquePlayers       []db.DailyCount     `json:"uniquePlayersPerDay"`
+	PeakConcurrentRooms []db.DailyCount     `json:"peakConcurrentRoomsPerDay"`
+	AvgSessionSeconds   float64             `json:"avgSessionSeconds"`
+	TopUserAgents       []db.UserAgentCount `json:"topUserAgents"`
+}
+
+func queryInt(r *http.Request, key string) int {
+	n, _ := strconv.Atoi(r.URL.Query().Get(key))
+	return n
+}
+
+func writeJoins(w http.ResponseWriter, joins []db.RoomJoinRecord) {
+	res := make([]JoinInfo, 0, len(joins))
+	for _, j := range joins {
+		res = append(res, JoinInfo{
+			ID:        j.ID,
+			RoomID:    j.RoomID,
+			Username:  j.Username,
+			ClientIP:  j.ClientIP,
+			UserAgent: j.UserAgent,
+			JoinedAt:  j.JoinedAt,
+			LeftAt:    j.LeftAt,
+		})
+	}
+	utils.JSON(w, res)
+}
+
+func (h *Handler) RoomJoins(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "missing params", http.StatusBadRequest)
+		return
+	}
+
+	joins, err := h.Joins.ListByRoom(r.Context(), id, queryInt(r, "limit"))
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJoins(w, joins)
+}
+
+func (h *Handler) UserJoins(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "missing params", http.StatusBadRequest)
+		return
+	}
+
+	joins, err := h.Joins.ListByUser(r.Context(), user, queryInt(r, "limit"))
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJoins(w, joins)
+}
+
+// JoinAnalytics reports usage over [from, to), given as YYYY-MM-DD days in tz.
+// Defaults to the last 30 days in UTC.
+func (h *Handler) JoinAnalytics(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	loc := time.UTC
+	if tz := q.Get("tz"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			http.Error(w, "invalid tz", http.StatusBadRequest)
+			return
+		}
+		loc = l
+	}
+
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	from := to.AddDate(0, 0, -30)
+
+	if v := q.Get("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			http.Error(w, "invalid from", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			http.Error(w, "invalid to", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	if !from.Before(to) || to.Sub(from) > 366*24*time.Hour {
+		http.Error(w, "invalid range", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	report := AnalyticsReport{From: from, To: to, TimeZone: loc.String()}
+
+	var err error
+	if report.UniquePlayers, err = h.Analytics.UniquePlayersPerDay(ctx, from, to, loc); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if report.PeakConcurrentRooms, err = h.Analytics.PeakConcurrentRoomsPerDay(ctx, from, to, loc); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	avg, err := h.Analytics.AverageSessionLength(ctx, from, to)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	report.AvgSessionSeconds = avg.Seconds()
+
+	if report.TopUserAgents, err = h.Analytics.TopUserAgents(ctx, from, to, queryInt(r, "top")); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, report)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/utils"
+	"my-source/loto-full/shared/web"
+)
+
+// Audited actions.
+const (
+	auditStartRoom     = "room.start"
+	auditSetInterval   = "room.interval"
+	auditRestartGame   = "room.restart"
+	auditBingoApprove  = "bingo.approve"
+	auditBingoReject   = "bingo.reject"
+	auditForceNumber   = "room.force_number"
+	auditKick          = "room.kick"
+	auditBan           = "room.ban"
+	auditUnban         = "room.unban"
+	auditTransfer      = "room.transfer_admin"
+	auditCoHost        = "room.cohost"
+	auditSetRole       = "room.role"
+	auditHold          = "room.hold"
+	auditVisibility    = "room.visibility"
+	auditInvite        = "room.invite"
+	auditRevokeInvites = "room.invite_revoke"
+	auditRoomMeta      = "room.meta"
+)
+
+// recordAudit stores which player did what to a room and what it
+// changed. Like recordJoin, a failed insert is logged and the action
+// still stands.
+func (h *Handler) recordAudit(r *http.Request, action, actor, roomID string, before, after any) {
+	h.insertAudit(r, db.ActorPlayer, action, actor, roomID, before, after)
+}
+
+// recordOperatorAudit is recordAudit for an action taken with an operator
+// credential; actor is the credential's name.
+func (h *Handler) recordOperatorAudit(r *http.Request, action, actor, roomID string, before, after any) {
+	h.insertAudit(r, db.ActorOperator, action, actor, roomID, before, after)
+}
+
+func (h *Handler) insertAudit(r *http.Request, kind, action, actor, roomID string, before, after any) {
+	e := db.AuditEventRecord{
+		Action:    action,
+		Actor:     actor,
+		ActorKind: kind,
+		RoomID:    roomID,
+		Before:    auditJSON(before),
+		After:     auditJSON(after),
+		ClientIP:  web.GetClientIP(r),
+	}
+
+	if err := h.Audit.Insert(r.Context(), e); err != nil {
+		log.Println("❌ audit:", action, roomID, err)
+	}
+}
+
+func auditJSON(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Println("❌ audit json:", err)
+		return nil
+	}
+	return b
+}
+
+type AuditEventInfo struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	ActorKind string          `json:"actorKind"`
+	RoomID    string          `json:"roomId"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	ClientIP  string          `json:"clientIp"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// AuditEvents lists audit events, newest first. Filters: room, actor,
+// actorKind (player, operator or system), action and a [from, to) range
+// as RFC 3339 timestamps.
+func (h *Handler) AuditEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := db.AuditFilter{
+		RoomID:    q.Get("room"),
+		Actor:     q.Get("actor"),
+		ActorKind: q.Get("actorKind"),
+		Action:    q.Get("action"),
+		Limit:     queryInt(r, "limit"),
+	}
+	switch f.ActorKind {
+	case "", db.ActorPlayer, db.ActorOperator, db.ActorSystem:
+	default:
+		http.Error(w, "invalid actorKind", http.StatusBadRequest)
+		return
+	}
+
+	for key, t := range map[string]*time.Time{"from": &f.From, "to": &f.To} {
+		if v := q.Get(key); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "invalid "+key, http.StatusBadRequest)
+				return
+			}
+			*t = parsed
+		}
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		http.Error(w, "invalid range", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.Audit.List(r.Context(), f)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	res := make([]AuditEventInfo, 0, len(events))
+	for _, e := range events {
+		res = append(res, AuditEventInfo{
+			ID:        e.ID,
+			Action:    e.Action,
+			Actor:     e.Actor,
+			ActorKind: e.ActorKind,
+			RoomID:    e.RoomID,
+			Before:    e.Before,
+			After:     e.After,
+			ClientIP:  e.ClientIP,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	utils.JSON(w, res)
+}
@@ -5,82 +5,114 @@ import (
 	"time"
 
 	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
 	"my-source/loto-full/backend/internal/utils"
 )
 
-func Bingo(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	user := r.URL.Query().Get("user")
+func (h *Handler) Bingo(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	id, user := s.Room, s.User
 	nums := r.URL.Query().Get("nums")
 
 	core.Mu.Lock()
-	defer core.Mu.Unlock()
-
 	rm := core.Rooms[id]
 	if rm == nil || rm.BingoOK {
+		core.Mu.Unlock()
 		utils.JSON(w, map[string]bool{"ok": false})
 		return
 	}
 
+	queued := false
 	for i, q := range rm.BingoQueue {
 		if q.User == user {
 			rm.BingoQueue[i].Nums = nums
-			rm.Paused = true
-			utils.JSON(w, map[string]bool{"ok": true})
-			return
+			queued = true
+			break
 		}
 	}
 
-	rm.BingoQueue = append(rm.BingoQueue, core.BingoItem{
-		User: user,
-		Nums: nums,
-	})
+	if !queued {
+		rm.BingoQueue = append(rm.BingoQueue, core.BingoItem{
+			User: user,
+			Nums: nums,
+		})
+	}
 	rm.Paused = true
+	gameID := rm.GameID
+	core.Mu.Unlock()
+
+	h.History.RecordClaim(gameID, user, nums)
 	utils.JSON(w, map[string]bool{"ok": true})
 }
 
-func BingoResult(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+func (h *Handler) BingoResult(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	id := s.Room
 	ok := r.URL.Query().Get("ok") == "1"
 
 	core.Mu.Lock()
-	defer core.Mu.Unlock()
-
 	rm := core.Rooms[id]
 	if rm == nil || len(rm.BingoQueue) == 0 {
+		core.Mu.Unlock()
 		return
 	}
 
+	claim := rm.BingoQueue[0]
+	gameID := rm.GameID
+	queued := len(rm.BingoQueue)
+	var dropped int64
+
 	if ok {
 		rm.BingoOK = true
 		rm.Running = false
 		rm.Paused = true
-		rm.Winner = rm.BingoQueue[0].User
-		rm.WinnerNums = rm.BingoQueue[0].Nums
+		rm.Winner = claim.User
+		rm.WinnerNums = claim.Nums
 		rm.ApprovedAt = time.Now().Unix()
 		rm.BingoQueue = nil
-		utils.JSON(w, map[string]bool{"ok": true})
-		return
+		dropped = rm.TakeForce()
+		rm.DiscloseForced()
+	} else {
+		rm.BingoQueue = rm.BingoQueue[1:]
+		rm.Paused = len(rm.BingoQueue) > 0
 	}
+	after := map[string]any{"queued": len(rm.BingoQueue), "running": rm.Running, "winner": rm.Winner}
+	core.Mu.Unlock()
+
+	h.History.ResolveForce(dropped, db.ForceDropped, 0)
+	h.History.RecordClaimResult(gameID, claim.User, claim.Nums, ok)
 
-	rm.BingoQueue = rm.BingoQueue[1:]
-	rm.Paused = len(rm.BingoQueue) > 0
+	action := auditBingoReject
+	if ok {
+		action = auditBingoApprove
+	}
+	h.recordAudit(r, action, s.User, id,
+		map[string]any{"claim": claim, "queued": queued, "gameId": gameID},
+		after)
 	utils.JSON(w, map[string]bool{"ok": true})
 }
 
-func RestartGame(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+func (h *Handler) RestartGame(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	id := s.Room
 
 	core.Mu.Lock()
-	defer core.Mu.Unlock()
-
 	rm := core.Rooms[id]
 	if rm == nil || !rm.BingoOK {
+		core.Mu.Unlock()
 		return
 	}
 
+	before := map[string]any{
+		"winner":     rm.Winner,
+		"winnerNums": rm.WinnerNums,
+		"called":     len(rm.Called),
+		"gameId":     rm.GameID,
+	}
+
 	rm.Running = false
 	rm.Paused = false
+	rm.Held = false
 	rm.Numbers = utils.NewNumbers()
 	rm.Called = nil
 	rm.Current = 0
@@ -89,6 +121,14 @@ func RestartGame(w http.ResponseWriter, r *http.Request) {
 	rm.Winner = ""
 	rm.WinnerNums = ""
 	rm.ApprovedAt = 0
-
+	rm.ForcedSeqs = nil
+	rm.ForcedNumbers = nil
+	// the finished game's history is closed; nothing is recorded until
+	// the next start opens a new one
+	rm.GameID = 0
+	core.Mu.Unlock()
+
+	h.recordAudit(r, auditRestartGame, s.User, id, before,
+		map[string]any{"winner": "", "called": 0, "gameId": 0})
 	utils.JSON(w, map[string]bool{"ok": true})
 }
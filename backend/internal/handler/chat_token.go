@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"my-source/loto-full/shared/token"
+)
+
+// ChatTokenSecret is shared with the chat server, which verifies the
+// membership tokens issued here without calling back. Set by LoadSecrets.
+var ChatTokenSecret string
+
+// chatTokenTTL is short so a kick or ban also ends chat access: players
+// get a fresh token from PingRoom, which a removed player cannot call.
+const chatTokenTTL = 5 * time.Minute
+
+// chatToken lets user read and post in room's chat, and with mod delete
+// messages there, see core.PermModerateChat. It is empty when CHAT_TOKEN_SECRET is not configured.
+func chatToken(room, user string, mod bool) string {
+	if ChatTokenSecret == "" {
+		return ""
+	}
+
+	tok, err := token.Sign([]byte(ChatTokenSecret), token.ChatClaims{
+		Room: room,
+		User: user,
+		Exp:  time.Now().Add(chatTokenTTL).Unix(),
+		Mod:  mod,
+	})
+	if err != nil {
+		log.Println("❌ chat token:", err)
+		return ""
+	}
+	return tok
+}
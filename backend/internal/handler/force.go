@@ -2,28 +2,48 @@ package handlers
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/utils"
+	"my-source/loto-full/shared/web"
 )
 
-var AdminSecret = os.Getenv("ADMIN_SECRET")
+// AdminSecret is the operator credential, set by LoadSecrets.
+var AdminSecret string
 
+// forceNumberEnabled is read per request so the switch can be flipped in
+// .env, which is loaded after package init.
+func forceNumberEnabled() bool {
+	return os.Getenv("FORCE_NUMBER_ENABLED") == "true"
+}
+
+// ForceNumberRequest asks for num to be the next ball in room ID. Reason
+// is mandatory and ends up in the forced_draws audit next to the operator
+// whose credential sent the request.
 type ForceNumberRequest struct {
 	ID     string `json:"id"`
 	Num    int    `json:"num"`
-	Secret string `json:"secret"`
+	Reason string `json:"reason"`
 }
 
-func ForceNumberHandler(w http.ResponseWriter, r *http.Request) {
+// ForceNumberHandler is an operator endpoint behind AdminOnly and the
+// FORCE_NUMBER_ENABLED switch. Each request is audited before it takes
+// effect, the draw is flagged in the game history, and players are shown
+// the forced numbers once a winner is approved.
+func (h *Handler) ForceNumberHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if AdminSecret == "" {
-		http.Error(w, "admin secret not configured", http.StatusInternalServerError)
+	if !forceNumberEnabled() {
+		http.Error(w, "force-number disabled", http.StatusForbidden)
 		return
 	}
 
@@ -33,26 +53,103 @@ func ForceNumberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ID == "" || req.Num <= 0 || req.Secret == "" {
+	actor := OperatorFrom(r)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.ID == "" || req.Num < 1 || req.Num > 90 || req.Reason == "" {
 		http.Error(w, "missing or invalid params", http.StatusBadRequest)
 		return
 	}
 
-	if req.Secret != AdminSecret {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	core.Mu.Lock()
+	rm := core.Rooms[req.ID]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	if !rm.Running || rm.BingoOK || utils.ContainsInt(rm.Called, req.Num) {
+		core.Mu.Unlock()
+		http.Error(w, "number cannot be forced now", http.StatusConflict)
+		return
+	}
+	gameID := rm.GameID
+	core.Mu.Unlock()
+
+	id, err := h.History.RecordForce(r.Context(), db.ForcedDrawRecord{
+		RoomID:   req.ID,
+		GameID:   gameID,
+		Number:   req.Num,
+		Actor:    actor,
+		Reason:   req.Reason,
+		ClientIP: web.GetClientIP(r),
+	})
+	if err != nil {
+		log.Println("❌ force-number audit:", req.ID, err)
+		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
 
+	// the game may have ended while the audit row was written
 	core.Mu.Lock()
-	defer core.Mu.Unlock()
+	rm = core.Rooms[req.ID]
+	if rm == nil || !rm.Running || rm.BingoOK || rm.GameID != gameID {
+		core.Mu.Unlock()
+		h.History.ResolveForce(id, db.ForceDropped, 0)
+		http.Error(w, "number cannot be forced now", http.StatusConflict)
+		return
+	}
+	prev := rm.NextForce
+	replaced := rm.TakeForce()
+	rm.NextForce, rm.ForceID = req.Num, id
+	core.Mu.Unlock()
 
-	room, ok := core.Rooms[req.ID]
-	if !ok {
-		http.Error(w, "room not found", http.StatusNotFound)
+	h.History.ResolveForce(replaced, db.ForceReplaced, 0)
+	h.recordOperatorAudit(r, auditForceNumber, actor, req.ID,
+		map[string]any{"nextForce": prev},
+		map[string]any{"nextForce": req.Num, "forcedDrawId": id, "reason": req.Reason})
+
+	log.Printf("🎯 FORCE-NUMBER room=%s num=%d actor=%s audit=%d\n", req.ID, req.Num, actor, id)
+	utils.JSON(w, map[string]any{"ok": true, "auditId": id})
+}
+
+type ForcedDrawInfo struct {
+	ID          int64      `json:"id"`
+	RoomID      string     `json:"roomId"`
+	GameID      int64      `json:"gameId"`
+	Number      int        `json:"number"`
+	Actor       string     `json:"actor"`
+	Reason      string     `json:"reason"`
+	ClientIP    string     `json:"clientIp"`
+	Status      string     `json:"status"`
+	Seq         int        `json:"seq"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	ResolvedAt  *time.Time `json:"resolvedAt"`
+}
+
+// ForcedDraws lists the force-number audit, newest first, optionally for
+// one room (?room=).
+func (h *Handler) ForcedDraws(w http.ResponseWriter, r *http.Request) {
+	list, err := h.Forced.List(r.Context(), r.URL.Query().Get("room"), queryInt(r, "limit"))
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
 
-	room.NextForce = req.Num
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ok"))
+	res := make([]ForcedDrawInfo, 0, len(list))
+	for _, f := range list {
+		res = append(res, ForcedDrawInfo{
+			ID:          f.ID,
+			RoomID:      f.RoomID,
+			GameID:      f.GameID,
+			Number:      f.Number,
+			Actor:       f.Actor,
+			Reason:      f.Reason,
+			ClientIP:    f.ClientIP,
+			Status:      f.Status,
+			Seq:         f.Seq,
+			RequestedAt: f.RequestedAt,
+			ResolvedAt:  f.ResolvedAt,
+		})
+	}
+	utils.JSON(w, res)
 }
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/services"
+)
+
+func TestForceNumber(t *testing.T) {
+	h, repos := newTestHandler(t)
+	operators = []operator{{name: "carol", secret: "op-key"}}
+	t.Cleanup(func() { operators = nil })
+
+	owner := createRoom(t, h, "force", "ann")
+	gameID := startGame(t, h, "force", owner.AdminToken)
+	force := AdminOnly(h.ForceNumberHandler)
+
+	req := ForceNumberRequest{ID: "force", Num: 42, Reason: "demo"}
+	if w := callJSON(force, "POST", "/admin/rooms/force-number", "op-key", req); w.Code != http.StatusForbidden {
+		t.Errorf("switched off: %d, want 403", w.Code)
+	}
+	t.Setenv("FORCE_NUMBER_ENABLED", "true")
+
+	tests := []struct {
+		name  string
+		token string
+		req   ForceNumberRequest
+		want  int
+	}{
+		{"no credential", "", req, http.StatusUnauthorized},
+		{"room session", owner.AdminToken, req, http.StatusUnauthorized},
+		{"no reason", "op-key", ForceNumberRequest{ID: "force", Num: 42}, http.StatusBadRequest},
+		{"out of range", "op-key", ForceNumberRequest{ID: "force", Num: 91, Reason: "demo"}, http.StatusBadRequest},
+		{"unknown room", "op-key", ForceNumberRequest{ID: "nope", Num: 42, Reason: "demo"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := callJSON(force, "POST", "/admin/rooms/force-number", tt.token, tt.req); w.Code != tt.want {
+			t.Errorf("%s: %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+
+	// holding the game keeps the loop from drawing the number meanwhile
+	core.Mu.Lock()
+	core.Rooms["force"].Held = true
+	core.Mu.Unlock()
+
+	w := callJSON(force, "POST", "/admin/rooms/force-number", "op-key", req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("force: %d %s", w.Code, w.Body)
+	}
+	first := decode[struct{ AuditID int64 }](t, w).AuditID
+	req.Num = 43
+	second := decode[struct{ AuditID int64 }](t, callJSON(force, "POST", "/admin/rooms/force-number", "op-key", req)).AuditID
+
+	core.Mu.Lock()
+	next, forceID := core.Rooms["force"].NextForce, core.Rooms["force"].ForceID
+	core.Mu.Unlock()
+	if next != 43 || forceID != second {
+		t.Errorf("room forces %d (audit %d), want 43 (audit %d)", next, forceID, second)
+	}
+
+	status := func() map[int64]db.ForcedDrawRecord {
+		list, _ := repos.Forced.List(context.Background(), "force", 0)
+		res := map[int64]db.ForcedDrawRecord{}
+		for _, f := range list {
+			res[f.ID] = f
+		}
+		return res
+	}
+	forced := status()
+	if f := forced[first]; f.Status != db.ForceReplaced || f.Actor != "carol" || f.GameID != gameID || f.ClientIP != "203.0.113.10" {
+		t.Errorf("replaced request = %+v", f)
+	}
+	if f := forced[second]; f.Status != db.ForcePending {
+		t.Errorf("second request = %+v, want pending", f)
+	}
+
+	events := decode[[]AuditEventInfo](t, call(h.AuditEvents, "GET", "/admin/audit?actor=carol&actorKind=operator", "op-key"))
+	if len(events) != 2 || events[0].Action != auditForceNumber {
+		t.Errorf("operator audit = %+v", events)
+	}
+	if w := call(h.AuditEvents, "GET", "/admin/audit?actorKind=admin", "op-key"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown actor kind: %d, want 400", w.Code)
+	}
+
+	// a forced ball was drawn before the room closed mid-game
+	repos.Games.InsertDraw(context.Background(), gameID, 1, 7, true)
+	h.Closer.Close(context.Background(), "force", services.CloseAdminLeft)
+
+	if f := status()[second]; f.Status != db.ForceCancelled || f.ResolvedAt == nil {
+		t.Errorf("request pending at close = %+v, want cancelled", f)
+	}
+	w = call(func(w http.ResponseWriter, r *http.Request) {
+		r.SetPathValue("id", strconv.FormatInt(gameID, 10))
+		h.GetGame(w, r)
+	}, "GET", "/games/"+strconv.FormatInt(gameID, 10), "op-key")
+	if g := decode[GameInfo](t, w); g.EndedAt == nil || g.ForcedDraws != 1 {
+		t.Errorf("game after close = %+v, want ended with its forced draw disclosed", g)
+	}
+}
@@ -5,24 +5,26 @@ import (
 	"strconv"
 
 	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
 	"my-source/loto-full/backend/internal/services"
 	"my-source/loto-full/backend/internal/utils"
 )
 
-func StartRoom(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	secret := r.URL.Query().Get("secret")
+func (h *Handler) StartRoom(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	id := s.Room
 
 	core.Mu.Lock()
 	rm := core.Rooms[id]
-	if rm == nil || rm.Running || rm.Secret != secret {
+	if rm == nil || rm.Running {
 		core.Mu.Unlock()
-		w.WriteHeader(403)
+		http.Error(w, "game already running", http.StatusConflict)
 		return
 	}
 
 	rm.Running = true
 	rm.Paused = false
+	rm.Held = false
 	rm.Numbers = utils.NewNumbers()
 	rm.Called = nil
 	rm.Current = 0
@@ -31,21 +33,81 @@ func StartRoom(w http.ResponseWriter, r *http.Request) {
 	rm.Winner = ""
 	rm.WinnerNums = ""
 	rm.ApprovedAt = 0
+	prevGame := rm.GameID
+	rm.GameID = 0
+	rm.ForcedSeqs = nil
+	rm.ForcedNumbers = nil
+	stale := rm.TakeForce()
+	admin := rm.Admin
 	core.Mu.Unlock()
 
-	go services.GameLoop(rm)
+	h.History.ResolveForce(stale, db.ForceDropped, 0)
+	gameID := h.History.StartGame(id, admin)
+
+	core.Mu.Lock()
+	rm.GameID = gameID
+	core.Mu.Unlock()
+
+	go services.GameLoop(rm, h.History)
+
+	h.recordAudit(r, auditStartRoom, s.User, id,
+		map[string]any{"running": false, "gameId": prevGame},
+		map[string]any{"running": true, "gameId": gameID})
 	utils.JSON(w, map[string]bool{"ok": true})
 }
 
-func SetInterval(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	v, _ := strconv.Atoi(r.URL.Query().Get("v"))
+// Bounds of the draw interval in seconds.
+const (
+	minInterval = 1
+	maxInterval = 60
+)
+
+// SetInterval sets the seconds between draws to ?v=, within
+// [minInterval, maxInterval].
+func (h *Handler) SetInterval(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	v, err := strconv.Atoi(r.URL.Query().Get("v"))
+	if err != nil || v < minInterval || v > maxInterval {
+		http.Error(w, "invalid interval", http.StatusBadRequest)
+		return
+	}
 
 	core.Mu.Lock()
-	if rm := core.Rooms[id]; rm != nil {
+	rm := core.Rooms[s.Room]
+	old := 0
+	if rm != nil {
+		old = rm.Interval
 		rm.Interval = v
 	}
 	core.Mu.Unlock()
 
+	if rm != nil {
+		h.recordAudit(r, auditSetInterval, s.User, s.Room,
+			map[string]int{"interval": old},
+			map[string]int{"interval": v})
+	}
+	utils.JSON(w, map[string]bool{"ok": true})
+}
+
+// HoldGame stops (held=1) or resumes (held=0) the draw without touching
+// the claim queue.
+func (h *Handler) HoldGame(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	held := r.URL.Query().Get("held") == "1"
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil || !rm.Running {
+		core.Mu.Unlock()
+		http.Error(w, "game not running", http.StatusConflict)
+		return
+	}
+	old := rm.Held
+	rm.Held = held
+	core.Mu.Unlock()
+
+	h.recordAudit(r, auditHold, s.User, s.Room,
+		map[string]bool{"held": old},
+		map[string]bool{"held": held})
 	utils.JSON(w, map[string]bool{"ok": true})
 }
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/services"
+	"my-source/loto-full/shared/web"
+)
+
+// Handler serves the loto HTTP API. Its stores are injected so the same
+// handlers run against Postgres or the in-memory repositories.
+type Handler struct {
+	Rooms     db.RoomRepository
+	Joins     db.JoinRepository
+	Games     db.GameRepository
+	Forced    db.ForcedDrawRepository
+	Audit     db.AuditRepository
+	Bans      db.BanRepository
+	Analytics db.AnalyticsRepository
+
+	History      *services.History
+	Closer       *services.Closer
+	Retention    *services.Retention
+	PersonalData *services.PersonalData
+}
+
+// Services groups the background workers the handlers call into.
+type Services struct {
+	History      *services.History
+	Closer       *services.Closer
+	Retention    *services.Retention
+	PersonalData *services.PersonalData
+}
+
+func New(repos *db.Repositories, svc Services) *Handler {
+	return &Handler{
+		Rooms:     repos.Rooms,
+		Joins:     repos.Joins,
+		Games:     repos.Games,
+		Forced:    repos.Forced,
+		Audit:     repos.Audit,
+		Bans:      repos.Bans,
+		Analytics: repos.Analytics,
+
+		History:   svc.History,
+		Closer:    svc.Closer,
+		Retention: svc.Retention,
+
+		PersonalData: svc.PersonalData,
+	}
+}
+
+// recordJoin stores who entered a room and from where. A failed insert is
+// logged but does not keep the player out of the game.
+func (h *Handler) recordJoin(r *http.Request, roomID, user string) {
+	if err := h.Joins.Insert(
+		r.Context(),
+		roomID,
+		user,
+		web.GetClientIP(r),
+		r.UserAgent(),
+	); err != nil {
+		log.Println("❌ record join:", roomID, user, err)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/services"
+)
+
+// newTestHandler wires the handlers to the in-memory repositories the way
+// cmd/main does, without a chat server.
+func newTestHandler(t *testing.T) (*Handler, *db.Repositories) {
+	t.Helper()
+	sessionSecret = []byte("test-secret")
+	inviteSecret = append([]byte("invite:"), sessionSecret...)
+
+	repos := db.NewMemory()
+	history := services.NewHistory(repos.Games, repos.Forced)
+	closer := services.NewCloser(repos.Rooms, repos.Joins, repos.Audit, history, "", "", false)
+	h := New(repos, Services{
+		History:   history,
+		Closer:    closer,
+		Retention: services.NewRetention(repos.Retention, services.RetentionPolicy{}),
+
+		PersonalData: services.NewPersonalData(repos.PersonalData, closer, "", ""),
+	})
+	return h, repos
+}
+
+// call runs fn on a request to target, authorized with token if set.
+func call(fn http.HandlerFunc, method, target, token string) *httptest.ResponseRecorder {
+	return send(fn, httptest.NewRequest(method, target, nil), token)
+}
+
+// callJSON is call with body sent as JSON.
+func callJSON(fn http.HandlerFunc, method, target, token string, body any) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	return send(fn, httptest.NewRequest(method, target, bytes.NewReader(b)), token)
+}
+
+func send(fn http.HandlerFunc, r *http.Request, token string) *httptest.ResponseRecorder {
+	r.RemoteAddr = "203.0.113.10:5000"
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	fn(w, r)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+type tokens struct {
+	Token      string `json:"token"`
+	AdminToken string `json:"adminToken"`
+}
+
+// createRoom opens a public room owned by owner, dropped again when the
+// test ends, and returns the owner's tokens.
+func createRoom(t *testing.T, h *Handler, id, owner string) tokens {
+	t.Helper()
+	w := call(h.CreateRoom, "POST", "/rooms/create?visibility=public&id="+id+"&user="+owner, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("create %s: %d %s", id, w.Code, w.Body)
+	}
+	t.Cleanup(func() {
+		core.Mu.Lock()
+		if rm := core.Rooms[id]; rm != nil {
+			rm.Running = false
+		}
+		delete(core.Rooms, id)
+		core.Mu.Unlock()
+	})
+	return decode[tokens](t, w)
+}
+
+// joinRoom lets user into the public room id and returns their session.
+func joinRoom(t *testing.T, h *Handler, id, user string) string {
+	t.Helper()
+	w := call(h.JoinRoom, "POST", "/rooms/join?id="+id+"&user="+user, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("join %s as %s: %d %s", id, user, w.Code, w.Body)
+	}
+	return decode[tokens](t, w).Token
+}
+
+// room returns the live room id; the caller must not hold core.Mu.
+func room(id string) *core.Room {
+	core.Mu.Lock()
+	defer core.Mu.Unlock()
+	return core.Rooms[id]
+}
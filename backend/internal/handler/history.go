@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/utils"
+)
+
+type GameInfo struct {
+	ID         int64      `json:"id"`
+	RoomID     string     `json:"roomId"`
+	Admin      string     `json:"admin"`
+	StartedAt  time.Time  `json:"startedAt"`
+	EndedAt    *time.Time `json:"endedAt"`
+	Winner     string     `json:"winner"`
+	WinnerNums string     `json:"winnerNums"`
+	Draws      int        `json:"draws"`
+	// ForcedDraws counts operator-forced balls; it is only disclosed once
+	// the game has ended.
+	ForcedDraws int `json:"forcedDraws"`
+}
+
+// ReplayEvent is one step of a game's timeline. Type is "draw", "claim" or
+// the claim's final status ("approved", "rejected", "dismissed"). Forced
+// marks operator-forced draws of games that have ended.
+type ReplayEvent struct {
+	Type   string    `json:"type"`
+	At     time.Time `json:"at"`
+	Offset int64     `json:"offsetMs"`
+	Seq    int       `json:"seq,omitempty"`
+	Number int       `json:"number,omitempty"`
+	Forced bool      `json:"forced,omitempty"`
+	User   string    `json:"user,omitempty"`
+	Nums   string    `json:"nums,omitempty"`
+}
+
+type GameReplay struct {
+	Game   GameInfo      `json:"game"`
+	Events []ReplayEvent `json:"events"`
+}
+
+// loadGame returns the game named in the path if the caller may read it.
+// Game ids are sequential, so operators may read any game but players
+// only those of the room their session is for, played since that room
+// was created; any other game is reported as not found.
+func (h *Handler) loadGame(w http.ResponseWriter, r *http.Request) (*db.GameRecord, bool) {
+	operator := operatorFor(utils.BearerToken(r)) != ""
+	var s Session
+	if !operator {
+		var ok bool
+		if s, ok = readSession(w, r); !ok {
+			return nil, false
+		}
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid game id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	g, err := h.Games.Get(r.Context(), id)
+	if err == nil && !operator {
+		err = h.gameInRoom(r.Context(), g, s.Room)
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return g, true
+}
+
+// gameInRoom returns ErrNotFound unless g was played in the current room
+// with id room, not in an earlier room that had the same id.
+func (h *Handler) gameInRoom(ctx context.Context, g *db.GameRecord, room string) error {
+	if g.RoomID != room {
+		return db.ErrNotFound
+	}
+	rec, err := h.Rooms.Get(ctx, room)
+	if err != nil {
+		return err
+	}
+	if g.StartedAt.Before(rec.CreatedAt) {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func gameInfo(g *db.GameRecord, draws []db.GameDrawRecord) GameInfo {
+	forced := 0
+	if g.EndedAt != nil {
+		for _, d := range draws {
+			if d.Forced {
+				forced++
+			}
+		}
+	}
+
+	return GameInfo{
+		ID:         g.ID,
+		RoomID:     g.RoomID,
+		Admin:      g.Admin,
+		StartedAt:  g.StartedAt,
+		EndedAt:    g.EndedAt,
+		Winner:     g.Winner,
+		WinnerNums: g.WinnerNums,
+		Draws:      len(draws),
+
+		ForcedDraws: forced,
+	}
+}
+
+func (h *Handler) GetGame(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.loadGame(w, r)
+	if !ok {
+		return
+	}
+
+	draws, err := h.Games.ListDraws(r.Context(), g.ID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, gameInfo(g, draws))
+}
+
+func (h *Handler) ReplayGame(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.loadGame(w, r)
+	if !ok {
+		return
+	}
+
+	draws, err := h.Games.ListDraws(r.Context(), g.ID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	claims, err := h.Games.ListClaims(r.Context(), g.ID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	events := make([]ReplayEvent, 0, len(draws)+2*len(claims))
+	for _, d := range draws {
+		events = append(events, ReplayEvent{
+			Type:   "draw",
+			At:     d.DrawnAt,
+			Seq:    d.Seq,
+			Number: d.Number,
+			Forced: d.Forced && g.EndedAt != nil,
+		})
+	}
+	for _, c := range claims {
+		events = append(events, ReplayEvent{
+			Type: "claim",
+			At:   c.ClaimedAt,
+			User: c.Username,
+			Nums: c.Nums,
+		})
+		if c.ResolvedAt != nil {
+			events = append(events, ReplayEvent{
+				Type: c.Status,
+				At:   *c.ResolvedAt,
+				User: c.Username,
+				Nums: c.Nums,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+	for i := range events {
+		events[i].Offset = events[i].At.Sub(g.StartedAt).Milliseconds()
+	}
+
+	utils.JSON(w, GameReplay{
+		Game:   gameInfo(g, draws),
+		Events: events,
+	})
+}
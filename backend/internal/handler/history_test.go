@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/services"
+)
+
+func startGame(t *testing.T, h *Handler, id, adminToken string) int64 {
+	t.Helper()
+	if w := call(RequirePermission(core.PermRunGame)(h.StartRoom), "POST", "/rooms/start", adminToken); w.Code != http.StatusOK {
+		t.Fatalf("start %s: %d %s", id, w.Code, w.Body)
+	}
+	core.Mu.Lock()
+	defer core.Mu.Unlock()
+	return core.Rooms[id].GameID
+}
+
+func getGame(h *Handler, gameID int64, token string) int {
+	r := call(func(w http.ResponseWriter, r *http.Request) {
+		r.SetPathValue("id", strconv.FormatInt(gameID, 10))
+		h.GetGame(w, r)
+	}, "GET", "/games/"+strconv.FormatInt(gameID, 10), token)
+	return r.Code
+}
+
+func TestGameAccess(t *testing.T) {
+	h, _ := newTestHandler(t)
+	operators = []operator{{name: "carol", secret: "op-key"}}
+	t.Cleanup(func() { operators = nil })
+
+	owner := createRoom(t, h, "games-a", "ann")
+	player := joinRoom(t, h, "games-a", "bob")
+	gameID := startGame(t, h, "games-a", owner.AdminToken)
+	outsider := createRoom(t, h, "games-b", "cy").Token
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"player of the room", player, http.StatusOK},
+		{"owner", owner.Token, http.StatusOK},
+		{"operator", "op-key", http.StatusOK},
+		{"player of another room", outsider, http.StatusNotFound},
+		{"no token", "", http.StatusUnauthorized},
+		{"bad token", "nope", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := getGame(h, gameID, tt.token); got != tt.want {
+			t.Errorf("%s: %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	// a new room under the same id does not see the earlier room's games
+	h.Closer.Close(context.Background(), "games-a", services.CloseStale)
+	newcomer := createRoom(t, h, "games-a", "dee").Token
+	if got := getGame(h, gameID, newcomer); got != http.StatusNotFound {
+		t.Errorf("member of a later room with the same id: %d, want 404", got)
+	}
+}
+
+func TestRestartClosesGameHistory(t *testing.T) {
+	h, repos := newTestHandler(t)
+	owner := createRoom(t, h, "restart", "ann")
+	gameID := startGame(t, h, "restart", owner.AdminToken)
+
+	core.Mu.Lock()
+	rm := core.Rooms["restart"]
+	rm.BingoOK, rm.Winner = true, "ann"
+	core.Mu.Unlock()
+
+	if w := call(RequirePermission(core.PermRunGame)(h.RestartGame), "POST", "/rooms/restart", owner.AdminToken); w.Code != http.StatusOK {
+		t.Fatalf("restart: %d %s", w.Code, w.Body)
+	}
+
+	core.Mu.Lock()
+	after := rm.GameID
+	core.Mu.Unlock()
+	if after != 0 {
+		t.Fatalf("GameID after restart = %d, want 0", after)
+	}
+
+	// a claim before the next start is not written to the finished game
+	h.History.RecordClaim(after, "ann", "1,2,3")
+	claims, _ := repos.Games.ListClaims(context.Background(), gameID)
+	if len(claims) != 0 {
+		t.Errorf("finished game got claims %+v", claims)
+	}
+}
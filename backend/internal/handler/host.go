@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/utils"
+)
+
+// TransferAdmin hands the host's room to ?user=, who must be present. The
+// caller's admin token stops working; the new admin collects theirs on
+// their next ping.
+func (h *Handler) TransferAdmin(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "missing params", http.StatusBadRequest)
+		return
+	}
+	if user == s.User {
+		http.Error(w, "already admin", http.StatusBadRequest)
+		return
+	}
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	if _, present := rm.Users[user]; !present {
+		core.Mu.Unlock()
+		http.Error(w, "player not in room", http.StatusNotFound)
+		return
+	}
+	rm.HandOff(user, utils.RandomKey())
+	core.Mu.Unlock()
+
+	if err := h.Rooms.SetAdmin(r.Context(), s.Room, user); err != nil {
+		log.Println("❌ transfer admin:", s.Room, err)
+	}
+	h.recordAudit(r, auditTransfer, s.User, s.Room,
+		map[string]string{"admin": s.User},
+		map[string]string{"admin": user})
+
+	utils.JSON(w, map[string]bool{"ok": true})
+}
+
+// SetCoHost names ?user= as the player who takes over if the host leaves
+// or stops pinging. An empty user clears it.
+func (h *Handler) SetCoHost(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	user := r.URL.Query().Get("user")
+	if user == s.User {
+		http.Error(w, "already admin", http.StatusBadRequest)
+		return
+	}
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	if _, present := rm.Users[user]; user != "" && !present {
+		core.Mu.Unlock()
+		http.Error(w, "player not in room", http.StatusNotFound)
+		return
+	}
+	prev := rm.CoHost
+	rm.CoHost = user
+	core.Mu.Unlock()
+
+	h.recordAudit(r, auditCoHost, s.User, s.Room,
+		map[string]string{"coHost": prev},
+		map[string]string{"coHost": user})
+
+	utils.JSON(w, map[string]bool{"ok": true})
+}
+
+// SetRole gives ?user= the ?role= moderator, player or spectator. Roles
+// outlast a dropped connection; a kick revokes them. A new moderator
+// collects their moderator token on their next ping.
+func (h *Handler) SetRole(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	user := r.URL.Query().Get("user")
+	role, ok := core.ParseRole(r.URL.Query().Get("role"))
+	if user == "" || !ok {
+		http.Error(w, "missing params", http.StatusBadRequest)
+		return
+	}
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	if user == rm.Admin {
+		core.Mu.Unlock()
+		http.Error(w, "use transfer-admin to change the owner", http.StatusBadRequest)
+		return
+	}
+	prev := rm.RoleOf(user)
+	lotos, claims := rm.SetRole(user, role, utils.RandomKey())
+	gameID := rm.GameID
+	core.Mu.Unlock()
+
+	if len(claims) > 0 {
+		h.History.DismissClaim(gameID, user)
+	}
+	h.recordAudit(r, auditSetRole, s.User, s.Room,
+		map[string]any{"user": user, "role": prev},
+		map[string]any{"user": user, "role": role, "lotos": lotos, "claims": claims})
+
+	utils.JSON(w, map[string]bool{"ok": true})
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/utils"
+	"my-source/loto-full/shared/token"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// inviteSecret signs invite links. It is derived from the session secret
+// so that an invite never verifies as a session or the other way round.
+// Set by LoadSecrets.
+var inviteSecret []byte
+
+const (
+	defaultInviteTTL = 24 * time.Hour
+	maxInviteTTL     = 7 * 24 * time.Hour
+
+	// inviteAlphabet leaves out look-alikes so codes can be read aloud.
+	inviteAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	inviteCodeLen  = 8
+)
+
+// InviteClaims is the payload of an invite link. Gen must match the
+// room's current InviteGen, so revoking invites voids every earlier link.
+type InviteClaims struct {
+	Room string `json:"room"`
+	Exp  int64  `json:"exp"`
+	Gen  int    `json:"gen"`
+}
+
+// inviteBaseURL is the frontend address invite links point to.
+func inviteBaseURL() string {
+	if u := os.Getenv("INVITE_BASE_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "http://localhost:3000"
+}
+
+func inviteLink(token string) string {
+	return inviteBaseURL() + "/?invite=" + url.QueryEscape(token)
+}
+
+func newInviteCode() string {
+	// bytes past the last full multiple of the alphabet are skipped so
+	// every letter is equally likely
+	limit := byte(256 / len(inviteAlphabet) * len(inviteAlphabet))
+	code := make([]byte, 0, inviteCodeLen)
+	b := make([]byte, 1)
+	for len(code) < inviteCodeLen {
+		if _, err := rand.Read(b); err != nil {
+			panic("crypto/rand: " + err.Error())
+		}
+		if b[0] < limit {
+			code = append(code, inviteAlphabet[int(b[0])%len(inviteAlphabet)])
+		}
+	}
+	return string(code)
+}
+
+// resolveInvite finds the room an invite link or code lets its holder
+// join; nil if the invite is unknown, expired or revoked.
+// The caller must hold core.Mu.
+func resolveInvite(invite string) *core.Room {
+	if !strings.Contains(invite, ".") {
+		return core.FindInviteCode(strings.ToUpper(invite))
+	}
+
+	var c InviteClaims
+	if err := token.Verify(inviteSecret, invite, &c); err != nil ||
+		time.Now().Unix() >= c.Exp {
+		return nil
+	}
+	rm := core.Rooms[c.Room]
+	if rm == nil || rm.InviteGen != c.Gen {
+		return nil
+	}
+	return rm
+}
+
+type InviteInfo struct {
+	Token     string    `json:"token"`
+	Code      string    `json:"code"`
+	URL       string    `json:"url"`
+	QR        string    `json:"qr"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreateInvite issues an invite to the owner's room, valid for ?ttl=
+// seconds (default a day, at most a week). It comes as a signed link and
+// as a short code; either joins without the secret.
+func (h *Handler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+
+	ttl := defaultInviteTTL
+	if v := queryInt(r, "ttl"); v > 0 {
+		ttl = time.Duration(v) * time.Second
+	}
+	if ttl > maxInviteTTL {
+		http.Error(w, "ttl too long", http.StatusBadRequest)
+		return
+	}
+	exp := time.Now().Add(ttl).Truncate(time.Second)
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	code := newInviteCode()
+	for core.FindInviteCode(code) != nil {
+		code = newInviteCode()
+	}
+	rm.AddInviteCode(code, exp)
+	gen := rm.InviteGen
+	core.Mu.Unlock()
+
+	tok, err := token.Sign(inviteSecret, InviteClaims{Room: s.Room, Exp: exp.Unix(), Gen: gen})
+	if err != nil {
+		log.Println("❌ invite token:", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(r, auditInvite, s.User, s.Room, nil,
+		map[string]any{"expiresAt": exp, "gen": gen})
+
+	utils.JSON(w, InviteInfo{
+		Token:     tok,
+		Code:      code,
+		URL:       inviteLink(tok),
+		QR:        "/rooms/invite/qr?invite=" + url.QueryEscape(tok),
+		ExpiresAt: exp,
+	})
+}
+
+// RevokeInvites voids every invite link and code of the owner's room.
+func (h *Handler) RevokeInvites(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	old := rm.InviteGen
+	rm.RevokeInvites()
+	gen := rm.InviteGen
+	core.Mu.Unlock()
+
+	h.recordAudit(r, auditRevokeInvites, s.User, s.Room,
+		map[string]int{"gen": old},
+		map[string]int{"gen": gen})
+
+	utils.JSON(w, map[string]bool{"ok": true})
+}
+
+// InviteQR renders the link of a valid ?invite= as a PNG QR code. Only
+// live invites are rendered, so this is no general-purpose QR service.
+func (h *Handler) InviteQR(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("invite")
+
+	core.Mu.Lock()
+	valid := strings.Contains(token, ".") && resolveInvite(token) != nil
+	core.Mu.Unlock()
+
+	if !valid {
+		http.Error(w, "invalid invite", http.StatusNotFound)
+		return
+	}
+
+	png, err := qrcode.Encode(inviteLink(token), qrcode.Medium, 256)
+	if err != nil {
+		log.Println("❌ invite qr:", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write(png)
+}
+
+// SetVisibility changes who sees the owner's room and how players get
+// in, see core.Visibility. An unlisted room needs a secret to join with.
+func (h *Handler) SetVisibility(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	v, ok := core.ParseVisibility(r.URL.Query().Get("v"))
+	if !ok || r.URL.Query().Get("v") == "" {
+		http.Error(w, "invalid visibility", http.StatusBadRequest)
+		return
+	}
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	if v == core.VisibilityUnlisted && rm.Secret == "" {
+		core.Mu.Unlock()
+		http.Error(w, "room has no secret", http.StatusBadRequest)
+		return
+	}
+	old := rm.Visibility
+	rm.Visibility = v
+	core.Mu.Unlock()
+
+	if err := h.Rooms.SetVisibility(r.Context(), s.Room, string(v)); err != nil {
+		log.Println("❌ set visibility:", s.Room, err)
+	}
+
+	h.recordAudit(r, auditVisibility, s.User, s.Room,
+		map[string]core.Visibility{"visibility": old},
+		map[string]core.Visibility{"visibility": v})
+
+	utils.JSON(w, map[string]bool{"ok": true})
+}
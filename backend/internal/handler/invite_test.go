@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/shared/token"
+)
+
+func TestInviteJoin(t *testing.T) {
+	h, repos := newTestHandler(t)
+	owner := createRoom(t, h, "inv", "ann")
+	manage := RequirePermission(core.PermManageRoom)
+	join := func(user, query string) int {
+		return call(h.JoinRoom, "POST", "/rooms/join?user="+user+query, "").Code
+	}
+
+	if w := call(manage(h.SetVisibility), "POST", "/rooms/visibility?v=private", owner.AdminToken); w.Code != http.StatusOK {
+		t.Fatalf("set visibility: %d %s", w.Code, w.Body)
+	}
+	if got := join("bob", "&id=inv"); got != http.StatusForbidden {
+		t.Errorf("joining a private room without an invite: %d, want 403", got)
+	}
+
+	player := issueSession("inv", "pat")
+	if w := call(manage(h.CreateInvite), "POST", "/rooms/invite", player); w.Code != http.StatusForbidden {
+		t.Errorf("player inviting: %d, want 403", w.Code)
+	}
+	if w := call(manage(h.CreateInvite), "POST", "/rooms/invite?ttl=999999999", owner.AdminToken); w.Code != http.StatusBadRequest {
+		t.Errorf("ttl past a week: %d, want 400", w.Code)
+	}
+
+	w := call(manage(h.CreateInvite), "POST", "/rooms/invite?ttl=600", owner.AdminToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("invite: %d %s", w.Code, w.Body)
+	}
+	inv := decode[InviteInfo](t, w)
+	if len(inv.Code) != inviteCodeLen || !strings.Contains(inv.URL, url.QueryEscape(inv.Token)) {
+		t.Errorf("invite = %+v", inv)
+	}
+
+	if got := join("bob", "&invite="+url.QueryEscape(inv.Token)); got != http.StatusOK {
+		t.Errorf("joining with the link: %d, want 200", got)
+	}
+	// codes are read aloud, so case does not matter
+	if got := join("cy", "&invite="+strings.ToLower(inv.Code)); got != http.StatusOK {
+		t.Errorf("joining with the code: %d, want 200", got)
+	}
+
+	qr := func(invite string) *http.Response {
+		return call(h.InviteQR, "GET", "/rooms/invite/qr?invite="+url.QueryEscape(invite), "").Result()
+	}
+	if res := qr(inv.Token); res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("qr of a live link: %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	if res := qr(inv.Code); res.StatusCode != http.StatusNotFound {
+		t.Errorf("qr of a code: %d, want 404", res.StatusCode)
+	}
+
+	core.Mu.Lock()
+	gen := core.Rooms["inv"].InviteGen
+	core.Mu.Unlock()
+	expired, _ := token.Sign(inviteSecret, InviteClaims{Room: "inv", Exp: time.Now().Add(-time.Minute).Unix(), Gen: gen})
+	if got := join("dee", "&invite="+url.QueryEscape(expired)); got != http.StatusForbidden {
+		t.Errorf("joining with an expired link: %d, want 403", got)
+	}
+	// an invite is no session and a session no invite
+	if got := join("dee", "&invite="+url.QueryEscape(player)); got != http.StatusForbidden {
+		t.Errorf("joining with a session as invite: %d, want 403", got)
+	}
+
+	if w := call(manage(h.RevokeInvites), "POST", "/rooms/invite/revoke", owner.AdminToken); w.Code != http.StatusOK {
+		t.Fatalf("revoke: %d %s", w.Code, w.Body)
+	}
+	if got := join("dee", "&invite="+url.QueryEscape(inv.Token)); got != http.StatusForbidden {
+		t.Errorf("joining with a revoked link: %d, want 403", got)
+	}
+	if got := join("dee", "&invite="+inv.Code); got != http.StatusForbidden {
+		t.Errorf("joining with a revoked code: %d, want 403", got)
+	}
+	if res := qr(inv.Token); res.StatusCode != http.StatusNotFound {
+		t.Errorf("qr of a revoked link: %d, want 404", res.StatusCode)
+	}
+
+	events, _ := repos.Audit.List(context.Background(), db.AuditFilter{RoomID: "inv", Actor: "ann"})
+	var actions []string
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	if strings.Join(actions, " ") != auditRevokeInvites+" "+auditInvite+" "+auditVisibility {
+		t.Errorf("audit = %v", actions)
+	}
+}
@@ -17,9 +17,9 @@ func getLotoID(r *http.Request) int {
 	return id
 }
 
-func SelectLoto(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	user := r.URL.Query().Get("user")
+func (h *Handler) SelectLoto(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	id, user := s.Room, s.User
 	lotoID := getLotoID(r)
 
 	core.Mu.Lock()
@@ -40,9 +40,9 @@ func SelectLoto(w http.ResponseWriter, r *http.Request) {
 	utils.JSON(w, map[string]bool{"ok": true})
 }
 
-func UnselectLoto(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	user := r.URL.Query().Get("user")
+func (h *Handler) UnselectLoto(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	id, user := s.Room, s.User
 	lotoID := getLotoID(r)
 
 	core.Mu.Lock()
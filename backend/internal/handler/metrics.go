@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"my-source/loto-full/backend/internal/utils"
+	"my-source/loto-full/shared/web"
+)
+
+// RateLimitMetrics lists each rate-limited route with its limits and how
+// many requests it has rejected since startup.
+func (h *Handler) RateLimitMetrics(w http.ResponseWriter, r *http.Request) {
+	utils.JSON(w, web.RateLimitStats())
+}
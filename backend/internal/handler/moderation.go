@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/utils"
+)
+
+// moderationTarget reads ?user= for a kick or ban and refuses the host
+// themself.
+func moderationTarget(w http.ResponseWriter, r *http.Request, s Session) (string, bool) {
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "missing params", http.StatusBadRequest)
+		return "", false
+	}
+	if user == s.User {
+		http.Error(w, "cannot remove yourself", http.StatusBadRequest)
+		return "", false
+	}
+	return user, true
+}
+
+// dropPlayer records the side effects of core.Room.Kick outside the lock.
+func (h *Handler) dropPlayer(r *http.Request, roomID, user string, gameID int64, claims []core.BingoItem) {
+	if err := h.Joins.MarkLeft(r.Context(), roomID, user); err != nil {
+		log.Println("❌ mark left:", roomID, user, err)
+	}
+	if len(claims) > 0 {
+		h.History.DismissClaim(gameID, user)
+	}
+}
+
+// KickPlayer removes ?user= from the host's room, releasing their lotos and
+// dropping their queued claims. The player may join again with the secret.
+func (h *Handler) KickPlayer(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	user, ok := moderationTarget(w, r, s)
+	if !ok {
+		return
+	}
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	if _, present := rm.Users[user]; !present {
+		core.Mu.Unlock()
+		http.Error(w, "player not in room", http.StatusNotFound)
+		return
+	}
+	lotos, claims := rm.Kick(user)
+	gameID := rm.GameID
+	core.Mu.Unlock()
+
+	h.dropPlayer(r, s.Room, user, gameID, claims)
+	h.recordAudit(r, auditKick, s.User, s.Room,
+		map[string]any{"user": user, "lotos": lotos, "claims": claims},
+		map[string]any{"user": user, "present": false})
+
+	utils.JSON(w, map[string]bool{"ok": true})
+}
+
+// BanPlayer bans ?user= from the host's room and kicks them. With ip=1 the
+// address they last joined this room from is banned as well. Bans are
+// stored first; if that fails nothing changes.
+func (h *Handler) BanPlayer(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	user, ok := moderationTarget(w, r, s)
+	if !ok {
+		return
+	}
+
+	ip := ""
+	core.Mu.Lock()
+	if rm := core.Rooms[s.Room]; rm != nil && r.URL.Query().Get("ip") == "1" {
+		ip = rm.JoinIPs[user]
+	}
+	core.Mu.Unlock()
+
+	ban := db.BanRecord{
+		RoomID:   s.Room,
+		Username: user,
+		ClientIP: ip,
+		Reason:   r.URL.Query().Get("reason"),
+		BannedBy: s.User,
+	}
+	banID, err := h.Bans.Upsert(r.Context(), ban)
+	if err != nil {
+		log.Println("❌ ban:", s.Room, user, err)
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	if rm.Banned == nil {
+		rm.Banned = map[string]bool{}
+	}
+	rm.Banned[user] = true
+	// a renewed ban replaces the address of the previous one
+	delete(rm.BannedIPs, banID)
+	if ip != "" {
+		if rm.BannedIPs == nil {
+			rm.BannedIPs = map[int64]string{}
+		}
+		rm.BannedIPs[banID] = ip
+	}
+	_, present := rm.Users[user]
+	lotos, claims := rm.Kick(user)
+	gameID := rm.GameID
+	core.Mu.Unlock()
+
+	if present {
+		h.dropPlayer(r, s.Room, user, gameID, claims)
+	}
+	h.recordAudit(r, auditBan, s.User, s.Room,
+		map[string]any{"user": user, "present": present, "lotos": lotos, "claims": claims},
+		map[string]any{"user": user, "ipBanned": ip != "", "reason": ban.Reason})
+
+	utils.JSON(w, map[string]any{"ok": true, "ipBanned": ip != ""})
+}
+
+// BanInfo is a ban as shown to the host; the banned address itself is
+// not disclosed.
+type BanInfo struct {
+	Username  string    `json:"username"`
+	IPBanned  bool      `json:"ipBanned"`
+	Reason    string    `json:"reason"`
+	BannedBy  string    `json:"bannedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ListBans lists the bans of the host's room, newest first.
+func (h *Handler) ListBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := h.Bans.ListByRoom(r.Context(), SessionFrom(r).Room)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	res := make([]BanInfo, 0, len(bans))
+	for _, b := range bans {
+		res = append(res, BanInfo{
+			Username:  b.Username,
+			IPBanned:  b.ClientIP != "",
+			Reason:    b.Reason,
+			BannedBy:  b.BannedBy,
+			CreatedAt: b.CreatedAt,
+		})
+	}
+	utils.JSON(w, res)
+}
+
+// UnbanPlayer lifts the ban on ?user= and on the address banned with it;
+// other bans of the same address stand.
+func (h *Handler) UnbanPlayer(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "missing params", http.StatusBadRequest)
+		return
+	}
+
+	ban, err := h.Bans.Delete(r.Context(), s.Room, user)
+	if errors.Is(err, db.ErrNotFound) {
+		http.Error(w, "not banned", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	core.Mu.Lock()
+	if rm := core.Rooms[s.Room]; rm != nil {
+		delete(rm.Banned, user)
+		delete(rm.BannedIPs, ban.ID)
+	}
+	core.Mu.Unlock()
+
+	h.recordAudit(r, auditUnban, s.User, s.Room,
+		map[string]any{"user": user, "ipBanned": ban.ClientIP != "", "reason": ban.Reason},
+		map[string]any{"user": user})
+
+	utils.JSON(w, map[string]bool{"ok": true})
+}
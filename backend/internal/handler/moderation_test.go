@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+)
+
+// joinFrom is JoinRoom for user connecting from addr (host:port).
+func joinFrom(h *Handler, id, user, addr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/rooms/join?id="+id+"&user="+user, nil)
+	r.RemoteAddr = addr
+	w := httptest.NewRecorder()
+	h.JoinRoom(w, r)
+	return w
+}
+
+func TestKickPlayer(t *testing.T) {
+	h, repos := newTestHandler(t)
+	owner := createRoom(t, h, "kick", "ann")
+	bob := joinRoom(t, h, "kick", "bob")
+	cy := joinRoom(t, h, "kick", "cy")
+	kick := RequirePermission(core.PermRemovePlayers)(h.KickPlayer)
+
+	core.Mu.Lock()
+	rm := core.Rooms["kick"]
+	rm.Lotos[5] = "bob"
+	rm.BingoQueue = []core.BingoItem{{User: "bob", Nums: "1,2"}}
+	rm.Paused = true
+	core.Mu.Unlock()
+
+	if w := call(kick, "POST", "/rooms/kick?user=bob", cy); w.Code != http.StatusForbidden {
+		t.Errorf("player kicking: %d, want 403", w.Code)
+	}
+	if w := call(kick, "POST", "/rooms/kick?user=ann", owner.AdminToken); w.Code == http.StatusOK {
+		t.Error("the owner kicked themselves")
+	}
+	if w := call(kick, "POST", "/rooms/kick?user=bob", owner.AdminToken); w.Code != http.StatusOK {
+		t.Fatalf("kick: %d %s", w.Code, w.Body)
+	}
+	if w := call(kick, "POST", "/rooms/kick?user=bob", owner.AdminToken); w.Code != http.StatusNotFound {
+		t.Errorf("kicking someone gone: %d, want 404", w.Code)
+	}
+
+	core.Mu.Lock()
+	_, present := rm.Users["bob"]
+	lotos, queued, paused := len(rm.Lotos), len(rm.BingoQueue), rm.Paused
+	core.Mu.Unlock()
+	if present || lotos != 0 || queued != 0 || paused {
+		t.Errorf("after kick: present %v, %d lotos, %d claims, paused %v", present, lotos, queued, paused)
+	}
+
+	// the kicked session is void, but the player may join again
+	if w := call(RequireSession(h.PingRoom), "POST", "/rooms/ping", bob); w.Code != http.StatusForbidden {
+		t.Errorf("ping with the kicked session: %d, want 403", w.Code)
+	}
+	joinRoom(t, h, "kick", "bob")
+
+	events, _ := repos.Audit.List(context.Background(), db.AuditFilter{RoomID: "kick", Action: auditKick})
+	if len(events) != 1 || events[0].Actor != "ann" {
+		t.Errorf("kick audit = %+v", events)
+	}
+}
+
+func TestBanPlayerByIP(t *testing.T) {
+	h, repos := newTestHandler(t)
+	owner := createRoom(t, h, "ban", "ann")
+	remove := RequirePermission(core.PermRemovePlayers)
+	ban := func(user, query string) *httptest.ResponseRecorder {
+		return call(remove(h.BanPlayer), "POST", "/rooms/ban?user="+user+query, owner.AdminToken)
+	}
+	unban := func(user string) {
+		t.Helper()
+		if w := call(remove(h.UnbanPlayer), "POST", "/rooms/unban?user="+user, owner.AdminToken); w.Code != http.StatusOK {
+			t.Fatalf("unban %s: %d %s", user, w.Code, w.Body)
+		}
+	}
+
+	if w := joinFrom(h, "ban", "bob", "198.51.100.7:4000"); w.Code != http.StatusOK {
+		t.Fatalf("join: %d %s", w.Code, w.Body)
+	}
+	// the ban takes the address of bob's session, not the join history
+	repos.Joins.Insert(context.Background(), "ban", "bob", "198.51.100.99", "")
+	if w := joinFrom(h, "ban", "cy", "198.51.100.7:4001"); w.Code != http.StatusOK {
+		t.Fatalf("join: %d %s", w.Code, w.Body)
+	}
+
+	w := ban("bob", "&ip=1&reason=spam")
+	if w.Code != http.StatusOK || !decode[struct{ IPBanned bool }](t, w).IPBanned {
+		t.Fatalf("ban: %d %s", w.Code, w.Body)
+	}
+	bans, _ := repos.Bans.ListByRoom(context.Background(), "ban")
+	if len(bans) != 1 || bans[0].ClientIP != "198.51.100.7" {
+		t.Fatalf("bans = %+v, want bob's join address", bans)
+	}
+
+	if w := joinFrom(h, "ban", "bob", "192.0.2.1:1"); w.Code != http.StatusForbidden {
+		t.Errorf("banned name joining: %d, want 403", w.Code)
+	}
+	if w := joinFrom(h, "ban", "dee", "198.51.100.7:4002"); w.Code != http.StatusForbidden {
+		t.Errorf("banned address joining: %d, want 403", w.Code)
+	}
+
+	// cy shares the address; lifting bob's ban keeps cy's
+	if w := ban("cy", "&ip=1"); w.Code != http.StatusOK {
+		t.Fatalf("ban cy: %d %s", w.Code, w.Body)
+	}
+	unban("bob")
+	if w := joinFrom(h, "ban", "eve", "198.51.100.7:4003"); w.Code != http.StatusForbidden {
+		t.Errorf("address still banned for cy: %d, want 403", w.Code)
+	}
+
+	// lifting a ban drops its address even once the row's is masked
+	if _, err := repos.Bans.Upsert(context.Background(), db.BanRecord{RoomID: "ban", Username: "cy", ClientIP: "198.51.100.0", BannedBy: "ann"}); err != nil {
+		t.Fatal(err)
+	}
+	unban("cy")
+	if w := joinFrom(h, "ban", "eve", "198.51.100.7:4004"); w.Code != http.StatusOK {
+		t.Errorf("address after every ban was lifted: %d %s", w.Code, w.Body)
+	}
+
+	if w := ban("fay", "&ip=1"); w.Code != http.StatusOK || decode[struct{ IPBanned bool }](t, w).IPBanned {
+		t.Errorf("ip ban of someone who never joined: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"my-source/loto-full/backend/internal/utils"
+)
+
+type RoomInfo struct {
+	ID          string     `json:"id"`
+	Admin       string     `json:"admin"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ClosedAt    *time.Time `json:"closedAt"`
+	CloseReason string     `json:"closeReason"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+}
+
+type ClaimInfo struct {
+	ID         int64      `json:"id"`
+	GameID     int64      `json:"gameId"`
+	Username   string     `json:"username"`
+	Nums       string     `json:"nums"`
+	Status     string     `json:"status"`
+	ClaimedAt  time.Time  `json:"claimedAt"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+}
+
+// UserBanInfo is a ban naming the user, as banned player or as host.
+type UserBanInfo struct {
+	RoomID    string    `json:"roomId"`
+	Username  string    `json:"username"`
+	ClientIP  string    `json:"clientIp"`
+	Reason    string    `json:"reason"`
+	BannedBy  string    `json:"bannedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type UserDataExport struct {
+	Username    string           `json:"username"`
+	ExportedAt  time.Time        `json:"exportedAt"`
+	Rooms       []RoomInfo       `json:"rooms"`
+	Joins       []JoinInfo       `json:"joins"`
+	Games       []GameInfo       `json:"games"`
+	Claims      []ClaimInfo      `json:"claims"`
+	AuditEvents []AuditEventInfo `json:"auditEvents"`
+	Bans        []UserBanInfo    `json:"bans"`
+	Chat        json.RawMessage  `json:"chat"`
+}
+
+// ExportUserData returns everything stored about a username as a JSON
+// download.
+func (h *Handler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "missing params", http.StatusBadRequest)
+		return
+	}
+
+	exp, err := h.PersonalData.Export(r.Context(), user)
+	if err != nil {
+		log.Println("❌ export user data:", err)
+		http.Error(w, "export failed", http.StatusBadGateway)
+		return
+	}
+
+	res := UserDataExport{
+		Username:    user,
+		ExportedAt:  time.Now(),
+		Rooms:       []RoomInfo{},
+		Joins:       []JoinInfo{},
+		Games:       []GameInfo{},
+		Claims:      []ClaimInfo{},
+		AuditEvents: []AuditEventInfo{},
+		Bans:        []UserBanInfo{},
+		Chat:        exp.Chat,
+	}
+	for _, rm := range exp.Data.Rooms {
+		res.Rooms = append(res.Rooms, RoomInfo{
+			ID:          rm.ID,
+			Admin:       rm.Admin,
+			CreatedAt:   rm.CreatedAt,
+			ClosedAt:    rm.ClosedAt,
+			CloseReason: rm.CloseReason,
+			Title:       rm.Title,
+			Description: rm.Description,
+			Tags:        rm.Tags,
+		})
+	}
+	for _, j := range exp.Data.Joins {
+		res.Joins = append(res.Joins, JoinInfo{
+			ID:        j.ID,
+			RoomID:    j.RoomID,
+			Username:  j.Username,
+			ClientIP:  j.ClientIP,
+			UserAgent: j.UserAgent,
+			JoinedAt:  j.JoinedAt,
+			LeftAt:    j.LeftAt,
+		})
+	}
+	for i := range exp.Data.Games {
+		res.Games = append(res.Games, gameInfo(&exp.Data.Games[i], nil))
+	}
+	for _, c := range exp.Data.Claims {
+		res.Claims = append(res.Claims, ClaimInfo{
+			ID:         c.ID,
+			GameID:     c.GameID,
+			Username:   c.Username,
+			Nums:       c.Nums,
+			Status:     c.Status,
+			ClaimedAt:  c.ClaimedAt,
+			ResolvedAt: c.ResolvedAt,
+		})
+	}
+
+	for _, e := range exp.Data.AuditEvents {
+		res.AuditEvents = append(res.AuditEvents, AuditEventInfo{
+			ID:        e.ID,
+			Action:    e.Action,
+			Actor:     e.Actor,
+			ActorKind: e.ActorKind,
+			RoomID:    e.RoomID,
+			Before:    e.Before,
+			After:     e.After,
+			ClientIP:  e.ClientIP,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	for _, b := range exp.Data.Bans {
+		res.Bans = append(res.Bans, UserBanInfo{
+			RoomID:    b.RoomID,
+			Username:  b.Username,
+			ClientIP:  b.ClientIP,
+			Reason:    b.Reason,
+			BannedBy:  b.BannedBy,
+			CreatedAt: b.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="loto-export.json"`)
+	utils.JSON(w, res)
+}
+
+// EraseUserData deletes or pseudonymises everything stored about a
+// username in both services.
+func (h *Handler) EraseUserData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "missing params", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.PersonalData.Erase(r.Context(), user)
+	if err != nil {
+		log.Println("❌ erase user data:", err)
+		http.Error(w, "erase failed", http.StatusBadGateway)
+		return
+	}
+
+	// logging the name next to its pseudonym would undo the erasure
+	log.Printf("🧽 ERASED user as %s\n", report.Pseudonym)
+	utils.JSON(w, report)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/services"
+)
+
+func TestEraseUserData(t *testing.T) {
+	h, repos := newTestHandler(t)
+	operators = []operator{{name: "carol", secret: "op-key"}}
+	t.Cleanup(func() { operators = nil })
+
+	owner := createRoom(t, h, "erase", "ann")
+	joinRoom(t, h, "erase", "bob")
+	gameID := startGame(t, h, "erase", owner.AdminToken)
+
+	core.Mu.Lock()
+	rm := core.Rooms["erase"]
+	rm.Held = true
+	rm.Lotos[5] = "bob"
+	rm.BingoQueue = []core.BingoItem{{User: "bob", Nums: "1,2"}}
+	rm.Paused = true
+	rm.SetRole("bob", core.RoleModerator, "mod-nonce")
+	core.Mu.Unlock()
+	h.History.RecordClaim(gameID, "bob", "1,2")
+
+	// an operator credential named like the player is not the player
+	repos.Audit.Insert(context.Background(), db.AuditEventRecord{Action: auditForceNumber, Actor: "bob", ActorKind: db.ActorOperator, RoomID: "erase"})
+
+	exp := decode[UserDataExport](t, call(h.ExportUserData, "GET", "/admin/users/export?user=bob", "op-key"))
+	if len(exp.Joins) != 1 || len(exp.Claims) != 1 {
+		t.Errorf("export: %d joins, %d claims; want 1, 1", len(exp.Joins), len(exp.Claims))
+	}
+	for _, e := range exp.AuditEvents {
+		if e.ActorKind != db.ActorPlayer {
+			t.Errorf("export has %s %s's event %q", e.ActorKind, e.Actor, e.Action)
+		}
+	}
+
+	w := call(h.EraseUserData, "POST", "/admin/users/erase?user=bob", "op-key")
+	if w.Code != http.StatusOK {
+		t.Fatalf("erase: %d %s", w.Code, w.Body)
+	}
+	report := decode[services.ErasureReport](t, w)
+	if report.LiveRooms != 1 || report.DB.Joins != 1 {
+		t.Errorf("report = %+v", report)
+	}
+
+	// bob left the way leaving does: lotos and claims released, role gone
+	core.Mu.Lock()
+	_, present := rm.Users["bob"]
+	lotos, queued, paused, role := len(rm.Lotos), len(rm.BingoQueue), rm.Paused, rm.RoleOf("bob")
+	core.Mu.Unlock()
+	if present || lotos != 0 || queued != 0 || paused || role != core.RolePlayer {
+		t.Errorf("after erasure: present %v, %d lotos, %d claims, paused %v, role %q", present, lotos, queued, paused, role)
+	}
+
+	claims, _ := repos.Games.ListClaims(context.Background(), gameID)
+	if len(claims) != 1 || claims[0].Status != db.ClaimDismissed || claims[0].Username != report.Pseudonym {
+		t.Errorf("claims after erasure = %+v", claims)
+	}
+	events, _ := repos.Audit.List(context.Background(), db.AuditFilter{Actor: "bob", ActorKind: db.ActorOperator})
+	if len(events) != 1 {
+		t.Errorf("operator audit after erasure = %+v", events)
+	}
+
+	joinRoom(t, h, "erase", "bob")
+}
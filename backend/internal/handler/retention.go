@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"my-source/loto-full/backend/internal/utils"
+)
+
+// RetentionPreview reports what the retention job would purge right now
+// without changing anything.
+func (h *Handler) RetentionPreview(w http.ResponseWriter, r *http.Request) {
+	report, err := h.Retention.Run(r.Context(), true)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, report)
+}
@@ -1,45 +1,144 @@
 package handlers
 
 import (
+	"encoding/json"
+	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"my-source/loto-full/backend/internal/core"
 	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/services"
 	"my-source/loto-full/backend/internal/utils"
+	"my-source/loto-full/shared/web"
 )
 
-func ListRooms(w http.ResponseWriter, r *http.Request) {
-	core.Mu.Lock()
-	defer core.Mu.Unlock()
+const (
+	defaultRoomPage = 20
+	maxRoomPage     = 100
+)
 
-	type Info struct {
-		ID      string `json:"id"`
-		Players int    `json:"players"`
-		Running bool   `json:"running"`
+type LobbyRoom struct {
+	ID      string `json:"id"`
+	Players int    `json:"players"`
+	Running bool   `json:"running"`
+	core.RoomMeta
+}
+
+// ListRooms pages through the public rooms, ordered by id. ?q= searches
+// the id, title, description and tags; ?language=, ?theme= and ?tag=
+// filter exactly. ?limit= defaults to 20, at most 100.
+func (h *Handler) ListRooms(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	language := q.Get("language")
+	theme := core.Theme(q.Get("theme"))
+	tag := strings.ToLower(strings.TrimSpace(q.Get("tag")))
+
+	limit := queryInt(r, "limit")
+	if limit <= 0 {
+		limit = defaultRoomPage
 	}
+	limit = min(limit, maxRoomPage)
+	offset := max(queryInt(r, "offset"), 0)
 
-	res := []Info{}
+	core.Mu.Lock()
+	// only public rooms are advertised
+	res := []LobbyRoom{}
 	for _, rm := range core.Rooms {
-		res = append(res, Info{
-			ID:      rm.ID,
-			Players: len(rm.Users),
-			Running: rm.Running,
+		if rm.Visibility != core.VisibilityPublic ||
+			!rm.Matches(q.Get("q")) ||
+			(language != "" && !strings.EqualFold(rm.Meta.Language, language)) ||
+			(theme != "" && rm.Meta.Theme != theme) ||
+			(tag != "" && !slices.Contains(rm.Meta.Tags, tag)) {
+			continue
+		}
+		res = append(res, LobbyRoom{
+			ID:       rm.ID,
+			Players:  len(rm.Users),
+			Running:  rm.Running,
+			RoomMeta: rm.Meta,
 		})
 	}
+	core.Mu.Unlock()
 
-	utils.JSON(w, res)
+	slices.SortFunc(res, func(a, b LobbyRoom) int { return strings.Compare(a.ID, b.ID) })
+	total := len(res)
+	res = res[min(offset, total):min(offset+limit, total)]
+
+	utils.JSON(w, map[string]any{
+		"rooms":  res,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
 }
 
-func CreateRoom(w http.ResponseWriter, r *http.Request) {
+// roomMetaFromQuery reads ?title=, ?description=, ?language=, ?theme= and
+// the comma-separated ?tags=.
+func roomMetaFromQuery(r *http.Request) core.RoomMeta {
+	q := r.URL.Query()
+	m := core.RoomMeta{
+		Title:       q.Get("title"),
+		Description: q.Get("description"),
+		Language:    q.Get("language"),
+		Theme:       core.Theme(q.Get("theme")),
+	}
+	if tags := q.Get("tags"); tags != "" {
+		m.Tags = strings.Split(tags, ",")
+	}
+	return m
+}
+
+func roomMetaRecord(m core.RoomMeta) db.RoomMeta {
+	return db.RoomMeta{
+		Title:       m.Title,
+		Description: m.Description,
+		Language:    m.Language,
+		Theme:       string(m.Theme),
+		Tags:        m.Tags,
+	}
+}
+
+// CreateRoom opens a room with ?visibility= (default unlisted). The
+// secret is required for unlisted rooms and optional otherwise. The
+// lobby metadata comes from the same parameters as /rooms/meta's fields.
+func (h *Handler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	user := r.URL.Query().Get("user")
 	secret := r.URL.Query().Get("secret")
+	visibility, ok := core.ParseVisibility(r.URL.Query().Get("visibility"))
 
-	if id == "" || user == "" || secret == "" {
+	if id == "" || user == "" || (secret == "" && visibility == core.VisibilityUnlisted) {
 		http.Error(w, "missing params", http.StatusBadRequest)
 		return
 	}
+	if !ok {
+		http.Error(w, "invalid visibility", http.StatusBadRequest)
+		return
+	}
+	if len(secret) > utils.MaxSecretLen {
+		http.Error(w, "secret too long", http.StatusBadRequest)
+		return
+	}
+	meta, err := roomMetaFromQuery(r).Clean()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// hashing is slow on purpose, keep it outside the lock
+	var secretHash string
+	if secret != "" {
+		var err error
+		secretHash, err = utils.HashSecret(secret)
+		if err != nil {
+			log.Println("❌ hash secret:", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
 
 	core.Mu.Lock()
 	if core.Rooms[id] != nil {
@@ -54,113 +153,234 @@ func CreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	core.Rooms[id] = &core.Room{
-		ID:       id,
-		Admin:    user,
-		Secret:   secret,
-		Users:    map[string]time.Time{user: time.Now()},
-		Numbers:  utils.NewNumbers(),
-		Called:   []int{},
-		Interval: 5,
-		Lotos:    map[int]string{},
+	adminNonce := utils.RandomKey()
+	rm := &core.Room{
+		ID:         id,
+		Admin:      user,
+		Secret:     secretHash,
+		AdminNonce: adminNonce,
+		Users:      map[string]time.Time{},
+		Numbers:    utils.NewNumbers(),
+		Called:     []int{},
+		Interval:   5,
+		Lotos:      map[int]string{},
+
+		Visibility: visibility,
+		Meta:       meta,
 	}
+	rm.JoinFrom(user, web.GetClientIP(r))
+	core.Rooms[id] = rm
 	core.Mu.Unlock()
-	_ = db.CreateRoom(
+	if err := h.Rooms.Create(
 		r.Context(),
 		id,
 		user,
-		secret,
-	)
+		secretHash,
+		string(visibility),
+		roomMetaRecord(meta),
+	); err != nil {
+		log.Println("❌ create room:", id, err)
+	}
+	if err := h.Bans.DeleteByRoom(r.Context(), id); err != nil {
+		log.Println("❌ clear bans:", id, err)
+	}
 
-	_ = db.InsertRoomJoin(
-		r.Context(),
-		id,
-		user,
-		utils.GetClientIP(r),
-		r.UserAgent(),
-	)
+	h.recordJoin(r, id, user)
 
-	utils.JSON(w, map[string]bool{"ok": true})
+	utils.JSON(w, map[string]any{
+		"ok":         true,
+		"token":      issueSession(id, user),
+		"adminToken": issueAdminSession(id, user, adminNonce),
+		"chatToken":  chatToken(id, user, core.RoleOwner.Can(core.PermModerateChat)),
+	})
 }
 
-func JoinRoom(w http.ResponseWriter, r *http.Request) {
+// JoinRoom lets ?user= into a room: a public room by ?id= alone, an
+// unlisted one with the ?secret=, and any room with an ?invite= link token
+// or code, which also stands in for the id. A name someone in the room
+// already goes by is refused with 409.
+func (h *Handler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	user := r.URL.Query().Get("user")
 	secret := r.URL.Query().Get("secret")
+	invite := r.URL.Query().Get("invite")
 
-	if id == "" || user == "" || secret == "" {
+	if user == "" || (id == "" && invite == "") {
 		http.Error(w, "missing params", http.StatusBadRequest)
 		return
 	}
 
 	core.Mu.Lock()
-	rm := core.Rooms[id]
-	if rm == nil || rm.Secret != secret {
-		core.Mu.Unlock()
+	var rm *core.Room
+	if invite != "" {
+		rm = resolveInvite(invite)
+		if rm != nil && id != "" && rm.ID != id {
+			rm = nil
+		}
+	} else {
+		rm = core.Rooms[id]
+	}
+	var secretHash string
+	var visibility core.Visibility
+	if rm != nil {
+		id, secretHash, visibility = rm.ID, rm.Secret, rm.Visibility
+	}
+	core.Mu.Unlock()
+
+	if rm == nil {
 		http.Error(w, "unauthorized", http.StatusForbidden)
 		return
 	}
+	if invite == "" {
+		allowed := visibility == core.VisibilityPublic ||
+			(visibility == core.VisibilityUnlisted && utils.CheckSecret(secretHash, secret))
+		if !allowed {
+			http.Error(w, "unauthorized", http.StatusForbidden)
+			return
+		}
+	}
 
-	rm.Users[user] = time.Now()
+	core.Mu.Lock()
+	rm = core.Rooms[id]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "unauthorized", http.StatusForbidden)
+		return
+	}
+	if rm.IsBanned(user, web.GetClientIP(r)) {
+		core.Mu.Unlock()
+		http.Error(w, "banned from room", http.StatusForbidden)
+		return
+	}
+	// whoever holds the name already has a session; rejoining goes
+	// through ping with it
+	if rm.NameTaken(user) {
+		core.Mu.Unlock()
+		http.Error(w, "name taken", http.StatusConflict)
+		return
+	}
+	rm.JoinFrom(user, web.GetClientIP(r))
 	core.Mu.Unlock()
 
-	_ = db.InsertRoomJoin(
-		r.Context(),
-		id,
-		user,
-		utils.GetClientIP(r),
-		r.UserAgent(),
-	)
+	h.recordJoin(r, id, user)
 
-	utils.JSON(w, map[string]bool{"ok": true})
+	utils.JSON(w, map[string]any{
+		"ok":        true,
+		"id":        id,
+		"token":     issueSession(id, user),
+		"chatToken": chatToken(id, user, false),
+	})
 }
 
-func LeaveRoom(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	user := r.URL.Query().Get("user")
+// LeaveRoom takes the player out of their room. They give up their role,
+// the co-host spot, their lotos and queued claims, so the name is free for
+// someone else. When the admin leaves the room is handed to someone else,
+// and closed only if nobody is left.
+func (h *Handler) LeaveRoom(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	id, user := s.Room, s.User
 
 	core.Mu.Lock()
-	defer core.Mu.Unlock()
-
 	rm := core.Rooms[id]
 	if rm == nil {
+		core.Mu.Unlock()
 		return
 	}
 
-	delete(rm.Users, user)
+	_, claims := rm.Quit(user)
+	gameID := rm.GameID
+	isAdmin := user == rm.Admin
+	core.Mu.Unlock()
 
-	for k, v := range rm.Lotos {
-		if v == user {
-			delete(rm.Lotos, k)
-		}
+	if len(claims) > 0 {
+		h.History.DismissClaim(gameID, user)
 	}
-
-	if user == rm.Admin {
-		delete(core.Rooms, id)
+	if isAdmin {
+		h.Closer.HandOff(r.Context(), id, user, services.CloseAdminLeft)
+	} else if err := h.Joins.MarkLeft(r.Context(), id, user); err != nil {
+		log.Println("❌ mark left:", id, user, err)
 	}
 
 	utils.JSON(w, map[string]bool{"ok": true})
 }
 
-func RoomState(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+// RoomState is the full state of the caller's room, for its members only.
+func (h *Handler) RoomState(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
 
 	core.Mu.Lock()
 	defer core.Mu.Unlock()
 
-	utils.JSON(w, core.Rooms[id])
+	utils.JSON(w, core.Rooms[s.Room])
 }
 
-func PingRoom(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	user := r.URL.Query().Get("user")
+func (h *Handler) PingRoom(w http.ResponseWriter, r *http.Request) {
+	s := SessionFrom(r)
+	id, user := s.Room, s.User
 
-	core.Mu.Lock()
-	defer core.Mu.Unlock()
+	res := map[string]any{"ok": true}
+	mod := false
 
+	core.Mu.Lock()
 	if rm := core.Rooms[id]; rm != nil {
-		rm.Users[user] = time.Now()
+		rm.Join(user)
+		res["role"] = rm.RoleOf(user)
+		// chat moderation follows the powers of the token pinging
+		mod = sessionRole(rm, s).Can(core.PermModerateChat)
+		// a new moderator collects their token the same way
+		if g, ok := rm.ModGrants[user]; ok && !g.Collected && s.Iat < g.At {
+			res["moderatorToken"] = issueModSession(id, user, g)
+		}
+		// a player the room was handed to collects their admin token here,
+		// with the session they already had when it was handed over
+		if rm.AdminPending && rm.Admin == user && s.Iat < rm.HandedOffAt {
+			res["adminToken"] = issueAdminSession(id, user, rm.AdminNonce)
+		}
 	}
+	core.Mu.Unlock()
 
-	utils.JSON(w, map[string]bool{"ok": true})
+	// keeps the short-lived chat token fresh
+	res["chatToken"] = chatToken(id, user, mod)
+	utils.JSON(w, res)
+}
+
+// SetRoomMeta replaces the lobby metadata of the owner's room with the
+// core.RoomMeta in the POST body.
+func (h *Handler) SetRoomMeta(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := SessionFrom(r)
+
+	var req core.RoomMeta
+	r.Body = http.MaxBytesReader(w, r.Body, 8<<10)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	meta, err := req.Clean()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	core.Mu.Lock()
+	rm := core.Rooms[s.Room]
+	if rm == nil {
+		core.Mu.Unlock()
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	old := rm.Meta
+	rm.Meta = meta
+	core.Mu.Unlock()
+
+	if err := h.Rooms.SetMeta(r.Context(), s.Room, roomMetaRecord(meta)); err != nil {
+		log.Println("❌ set room meta:", s.Room, err)
+	}
+
+	h.recordAudit(r, auditRoomMeta, s.User, s.Room, old, meta)
+
+	utils.JSON(w, meta)
 }
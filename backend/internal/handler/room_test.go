@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"my-source/loto-full/backend/internal/core"
+)
+
+func TestLeaveFreesTheName(t *testing.T) {
+	h, _ := newTestHandler(t)
+	owner := createRoom(t, h, "leave", "ann")
+	bob := joinRoom(t, h, "leave", "bob")
+
+	manage := RequirePermission(core.PermManageRoles)
+	if w := call(manage(h.SetRole), "POST", "/rooms/role?user=bob&role=moderator", owner.AdminToken); w.Code != http.StatusOK {
+		t.Fatalf("role: %d %s", w.Code, w.Body)
+	}
+	if w := call(manage(h.SetCoHost), "POST", "/rooms/cohost?user=bob", owner.AdminToken); w.Code != http.StatusOK {
+		t.Fatalf("co-host: %d %s", w.Code, w.Body)
+	}
+	if w := call(RequireSession(h.LeaveRoom), "POST", "/rooms/leave", bob); w.Code != http.StatusOK {
+		t.Fatalf("leave: %d %s", w.Code, w.Body)
+	}
+
+	rm := room("leave")
+	core.Mu.Lock()
+	role, coHost := rm.RoleOf("bob"), rm.CoHost
+	_, granted := rm.ModGrants["bob"]
+	core.Mu.Unlock()
+	if role != core.RolePlayer || coHost != "" || granted {
+		t.Errorf("after leaving bob is %s, co-host %q, grant kept %v", role, coHost, granted)
+	}
+
+	// someone else may now join as bob, without bob's powers
+	if w := call(h.JoinRoom, "POST", "/rooms/join?id=leave&user=bob", ""); w.Code != http.StatusOK {
+		t.Errorf("joining under a freed name: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/utils"
+	"my-source/loto-full/shared/token"
+	"my-source/loto-full/shared/web"
+)
+
+// sessionSecret signs player session tokens. Without SESSION_SECRET a
+// random one is used, so sessions do not survive a restart. Set by
+// LoadSecrets.
+var sessionSecret []byte
+
+const sessionTTL = 24 * time.Hour
+
+// LoadSecrets reads the signing secrets and operator credentials from the
+// environment. Package init runs before main loads .env, so main calls
+// this once .env is in place and before serving.
+func LoadSecrets() error {
+	AdminSecret = os.Getenv("ADMIN_SECRET")
+	ChatTokenSecret = os.Getenv("CHAT_TOKEN_SECRET")
+	sessionSecret = loadSessionSecret()
+	inviteSecret = append([]byte("invite:"), sessionSecret...)
+
+	var err error
+	operators, err = loadOperators()
+	return err
+}
+
+func loadSessionSecret() []byte {
+	if s := os.Getenv("SESSION_SECRET"); s != "" {
+		return []byte(s)
+	}
+
+	log.Println("⚠️ SESSION_SECRET not set, sessions end on restart")
+	return []byte(utils.RandomKey() + utils.RandomKey())
+}
+
+// Session is who a request acts as. It is bound to one room and one user
+// and issued by CreateRoom and JoinRoom.
+type Session struct {
+	Room string `json:"room"`
+	User string `json:"user"`
+	Exp  int64  `json:"exp"`
+	// Iat is the issue time in unix milliseconds; a kick voids every
+	// session issued before it.
+	Iat int64 `json:"iat"`
+
+	// AdminKey is only set in the admin token handed to the room's
+	// creator, or to whoever it was later handed to; it must match the
+	// key derived from the room's current AdminNonce.
+	AdminKey string `json:"adminKey,omitempty"`
+	// ModKey is only set in a moderator token and must match the key of
+	// the user's current moderator grant.
+	ModKey string `json:"modKey,omitempty"`
+}
+
+func issueSession(room, user string) string {
+	return signSession(Session{Room: room, User: user})
+}
+
+// issueAdminSession is the host credential, distinct from the player
+// session and from the room secret every player knows.
+func issueAdminSession(room, user, nonce string) string {
+	return signSession(Session{Room: room, User: user, AdminKey: grantKey("admin", room, user, nonce)})
+}
+
+// issueModSession is a moderator's credential for their current grant.
+func issueModSession(room, user string, g core.ModGrant) string {
+	return signSession(Session{Room: room, User: user, ModKey: grantKey("mod", room, user, g.Nonce)})
+}
+
+// grantKey derives the key an admin or moderator token carries from the
+// grant's nonce and the session secret, so neither the room nor its
+// snapshot holds a usable key.
+func grantKey(kind, room, user, nonce string) string {
+	m := hmac.New(sha256.New, sessionSecret)
+	m.Write([]byte(kind + "\x00" + room + "\x00" + user + "\x00" + nonce))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func signSession(s Session) string {
+	now := time.Now()
+	s.Exp = now.Add(sessionTTL).Unix()
+	s.Iat = now.UnixMilli()
+	tok, err := token.Sign(sessionSecret, s)
+	if err != nil {
+		log.Println("❌ session token:", err)
+		return ""
+	}
+	return tok
+}
+
+type sessionKey struct{}
+
+// RequireSession resolves "Authorization: Bearer <token>" into a Session.
+// An "id" query parameter, if sent, must name the session's room.
+func RequireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, ok := readSession(w, r)
+		if !ok {
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, s)))
+	}
+}
+
+// sessionRole is the role s acts with in rm. The owner's powers come
+// only with the admin token carrying the room's current key, and a
+// moderator's only with the token of their current grant; with a plain
+// session both act as a player. The caller must hold Mu.
+func sessionRole(rm *core.Room, s Session) core.Role {
+	switch role := rm.RoleOf(s.User); role {
+	case core.RoleOwner:
+		key := grantKey("admin", rm.ID, s.User, rm.AdminNonce)
+		if rm.AdminNonce != "" && subtle.ConstantTimeCompare([]byte(s.AdminKey), []byte(key)) == 1 {
+			return role
+		}
+		return core.RolePlayer
+	case core.RoleModerator:
+		g, ok := rm.ModGrants[s.User]
+		key := grantKey("mod", rm.ID, s.User, g.Nonce)
+		if ok && subtle.ConstantTimeCompare([]byte(s.ModKey), []byte(key)) == 1 {
+			return role
+		}
+		return core.RolePlayer
+	default:
+		return role
+	}
+}
+
+// RequirePermission guards room actions by role, see core.Permission and
+// sessionRole. A missing or bad token is 401, a role without p 403.
+func RequirePermission(p core.Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			s, ok := readSession(w, r)
+			if !ok {
+				return
+			}
+
+			core.Mu.Lock()
+			rm := core.Rooms[s.Room]
+			allowed := false
+			if rm != nil {
+				role := sessionRole(rm, s)
+				allowed = role.Can(p)
+				// the token has reached its holder, ping stops handing it out
+				switch role {
+				case core.RoleOwner:
+					rm.AdminPending = false
+				case core.RoleModerator:
+					g := rm.ModGrants[s.User]
+					g.Collected = true
+					rm.ModGrants[s.User] = g
+				}
+			}
+			core.Mu.Unlock()
+
+			if rm == nil {
+				http.Error(w, "room not found", http.StatusNotFound)
+				return
+			}
+			if !allowed {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			next(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, s)))
+		}
+	}
+}
+
+func readSession(w http.ResponseWriter, r *http.Request) (Session, bool) {
+	var s Session
+	if err := token.Verify(sessionSecret, utils.BearerToken(r), &s); err != nil ||
+		s.Room == "" || s.User == "" || time.Now().Unix() >= s.Exp {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return s, false
+	}
+
+	if id := r.URL.Query().Get("id"); id != "" && id != s.Room {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return s, false
+	}
+
+	ip := web.GetClientIP(r)
+	core.Mu.Lock()
+	removed := false
+	if rm := core.Rooms[s.Room]; rm != nil {
+		kickedAt, kicked := rm.Kicked[s.User]
+		removed = rm.IsBanned(s.User, ip) || (kicked && kickedAt >= s.Iat)
+	}
+	core.Mu.Unlock()
+
+	if removed {
+		http.Error(w, "removed from room", http.StatusForbidden)
+		return s, false
+	}
+	return s, true
+}
+
+// SessionRateKey keys the per-session rate limit by room and user, so
+// joining again for a fresh token does not refill the bucket.
+func SessionRateKey(r *http.Request) string {
+	var s Session
+	if err := token.Verify(sessionSecret, utils.BearerToken(r), &s); err != nil {
+		return ""
+	}
+	return s.Room + "\x00" + s.User
+}
+
+// SessionFrom returns the session set by RequireSession.
+func SessionFrom(r *http.Request) Session {
+	s, _ := r.Context().Value(sessionKey{}).(Session)
+	return s
+}
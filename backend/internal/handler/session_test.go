@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/shared/token"
+)
+
+func TestLoadSecrets(t *testing.T) {
+	session, admin, chat, ops := sessionSecret, AdminSecret, ChatTokenSecret, operators
+	t.Cleanup(func() {
+		sessionSecret, AdminSecret, ChatTokenSecret, operators = session, admin, chat, ops
+		inviteSecret = append([]byte("invite:"), sessionSecret...)
+	})
+
+	t.Setenv("SESSION_SECRET", "from-env")
+	t.Setenv("ADMIN_SECRET", "adm")
+	t.Setenv("CHAT_TOKEN_SECRET", "chat-key")
+	t.Setenv("OPERATOR_KEYS", "carol:c-key")
+	if err := LoadSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if string(sessionSecret) != "from-env" || AdminSecret != "adm" {
+		t.Errorf("sessionSecret = %q, AdminSecret = %q", sessionSecret, AdminSecret)
+	}
+
+	// the chat server checks the token with the same shared code
+	c, err := token.VerifyChat([]byte("chat-key"), chatToken("r1", "ann", true))
+	if err != nil || c.Room != "r1" || c.User != "ann" || !c.Mod {
+		t.Errorf("chat token claims = %+v, %v", c, err)
+	}
+
+	t.Setenv("OPERATOR_KEYS", "no-secret")
+	if err := LoadSecrets(); err == nil {
+		t.Error("malformed OPERATOR_KEYS accepted")
+	}
+}
+
+// testRoom registers a room owned by "owner" with "mod" as a moderator
+// and "spec" as a spectator, and removes it when the test ends.
+func testRoom(t *testing.T, id string) *core.Room {
+	t.Helper()
+	sessionSecret = []byte("test-secret")
+
+	rm := &core.Room{
+		ID:         id,
+		Admin:      "owner",
+		AdminNonce: "admin-nonce",
+		Users:      map[string]time.Time{},
+		Lotos:      map[int]string{},
+	}
+	rm.SetRole("mod", core.RoleModerator, "mod-nonce")
+	rm.SetRole("spec", core.RoleSpectator, "")
+
+	core.Mu.Lock()
+	core.Rooms[id] = rm
+	core.Mu.Unlock()
+	t.Cleanup(func() {
+		core.Mu.Lock()
+		delete(core.Rooms, id)
+		core.Mu.Unlock()
+	})
+	return rm
+}
+
+func callWith(p core.Permission, token string) int {
+	h := RequirePermission(p)(func(w http.ResponseWriter, r *http.Request) {})
+	r := httptest.NewRequest("POST", "/", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w.Code
+}
+
+func TestRequirePermissionRoles(t *testing.T) {
+	rm := testRoom(t, "perm-room")
+	owner := issueAdminSession(rm.ID, "owner", "admin-nonce")
+	mod := issueModSession(rm.ID, "mod", rm.ModGrants["mod"])
+
+	tests := []struct {
+		name  string
+		token string
+		perm  core.Permission
+		want  int
+	}{
+		{"owner manages the room", owner, core.PermManageRoom, http.StatusOK},
+		{"owner runs the game", owner, core.PermRunGame, http.StatusOK},
+		{"owner without admin token", issueSession(rm.ID, "owner"), core.PermRunGame, http.StatusForbidden},
+		{"owner token with old nonce", issueAdminSession(rm.ID, "owner", "old"), core.PermRunGame, http.StatusForbidden},
+		{"moderator holds the draw", mod, core.PermPause, http.StatusOK},
+		{"moderator cannot run the game", mod, core.PermRunGame, http.StatusForbidden},
+		{"moderator with player token", issueSession(rm.ID, "mod"), core.PermPause, http.StatusForbidden},
+		{"moderator with player token plays", issueSession(rm.ID, "mod"), core.PermPlay, http.StatusOK},
+		{"player plays", issueSession(rm.ID, "pat"), core.PermPlay, http.StatusOK},
+		{"player cannot kick", issueSession(rm.ID, "pat"), core.PermRemovePlayers, http.StatusForbidden},
+		{"player forging admin key", signSession(Session{Room: rm.ID, User: "pat", AdminKey: grantKey("admin", rm.ID, "owner", "admin-nonce")}), core.PermRunGame, http.StatusForbidden},
+		{"spectator cannot play", issueSession(rm.ID, "spec"), core.PermPlay, http.StatusForbidden},
+		{"no token", "", core.PermPlay, http.StatusUnauthorized},
+		{"garbage token", "x.y", core.PermPlay, http.StatusUnauthorized},
+		{"unknown room", issueSession("nowhere", "pat"), core.PermPlay, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if got := callWith(tt.perm, tt.token); got != tt.want {
+			t.Errorf("%s: %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequirePermissionAfterChanges(t *testing.T) {
+	rm := testRoom(t, "perm-change")
+	owner := issueAdminSession(rm.ID, "owner", "admin-nonce")
+	mod := issueModSession(rm.ID, "mod", rm.ModGrants["mod"])
+
+	core.Mu.Lock()
+	rm.HandOff("mod", "next-nonce")
+	core.Mu.Unlock()
+
+	if got := callWith(core.PermRunGame, owner); got != http.StatusForbidden {
+		t.Errorf("old owner after hand-off: %d, want 403", got)
+	}
+	// the moderator token does not carry the owner's key
+	if got := callWith(core.PermRunGame, mod); got != http.StatusForbidden {
+		t.Errorf("new owner with moderator token: %d, want 403", got)
+	}
+	if got := callWith(core.PermRunGame, issueAdminSession(rm.ID, "mod", "next-nonce")); got != http.StatusOK {
+		t.Errorf("new owner with admin token: %d, want 200", got)
+	}
+
+	core.Mu.Lock()
+	rm.SetRole("mod2", core.RoleModerator, "n1")
+	mod2 := issueModSession(rm.ID, "mod2", rm.ModGrants["mod2"])
+	rm.SetRole("mod2", core.RolePlayer, "")
+	rm.SetRole("mod2", core.RoleModerator, "n2")
+	core.Mu.Unlock()
+
+	if got := callWith(core.PermPause, mod2); got != http.StatusForbidden {
+		t.Errorf("token of a revoked grant: %d, want 403", got)
+	}
+}
+
+func TestRequirePermissionKicked(t *testing.T) {
+	rm := testRoom(t, "perm-kick")
+	tok := issueSession(rm.ID, "pat")
+
+	core.Mu.Lock()
+	rm.Kick("pat")
+	core.Mu.Unlock()
+
+	if got := callWith(core.PermPlay, tok); got != http.StatusForbidden {
+		t.Errorf("kicked player: %d, want 403", got)
+	}
+}
+
+func TestRequirePermissionCollectsGrant(t *testing.T) {
+	rm := testRoom(t, "perm-collect")
+	rm.AdminPending = true
+
+	callWith(core.PermPause, issueModSession(rm.ID, "mod", rm.ModGrants["mod"]))
+	callWith(core.PermRunGame, issueAdminSession(rm.ID, "owner", "admin-nonce"))
+
+	core.Mu.Lock()
+	defer core.Mu.Unlock()
+	if !rm.ModGrants["mod"].Collected {
+		t.Error("moderator grant not marked collected")
+	}
+	if rm.AdminPending {
+		t.Error("admin token still pending")
+	}
+}
+
+func TestSetRoleGrantsModeratorToken(t *testing.T) {
+	h, repos := newTestHandler(t)
+	owner := createRoom(t, h, "roles", "ann")
+	bob := joinRoom(t, h, "roles", "bob")
+	startGame(t, h, "roles", owner.AdminToken)
+
+	setRole := RequirePermission(core.PermManageRoles)(h.SetRole)
+	hold := RequirePermission(core.PermPause)(h.HoldGame)
+	ping := RequireSession(h.PingRoom)
+	type pingRes struct {
+		Role           core.Role `json:"role"`
+		ModeratorToken string    `json:"moderatorToken"`
+	}
+
+	if w := call(hold, "POST", "/rooms/hold?held=1", bob); w.Code != http.StatusForbidden {
+		t.Errorf("player holding: %d, want 403", w.Code)
+	}
+	if w := call(setRole, "POST", "/rooms/role?user=cy&role=moderator", bob); w.Code != http.StatusForbidden {
+		t.Errorf("player granting roles: %d, want 403", w.Code)
+	}
+
+	// the grant must postdate bob's session for ping to hand it over
+	time.Sleep(2 * time.Millisecond)
+	if w := call(setRole, "POST", "/rooms/role?user=bob&role=moderator", owner.AdminToken); w.Code != http.StatusOK {
+		t.Fatalf("grant: %d %s", w.Code, w.Body)
+	}
+	// a newcomer taking bob's name cannot collect the grant
+	if w := call(h.JoinRoom, "POST", "/rooms/join?id=roles&user=bob", ""); w.Code != http.StatusConflict {
+		t.Errorf("joining as the moderator: %d, want 409", w.Code)
+	}
+
+	res := decode[pingRes](t, call(ping, "POST", "/rooms/ping", bob))
+	if res.Role != core.RoleModerator || res.ModeratorToken == "" {
+		t.Fatalf("ping after grant = %+v", res)
+	}
+	mod := res.ModeratorToken
+	if w := call(hold, "POST", "/rooms/hold?held=1", mod); w.Code != http.StatusOK {
+		t.Errorf("moderator holding: %d %s", w.Code, w.Body)
+	}
+	if w := call(hold, "POST", "/rooms/hold?held=0", bob); w.Code != http.StatusForbidden {
+		t.Errorf("moderator with the player session: %d, want 403", w.Code)
+	}
+	if res := decode[pingRes](t, call(ping, "POST", "/rooms/ping", bob)); res.ModeratorToken != "" {
+		t.Error("moderator token handed out again after it was used")
+	}
+
+	if w := call(setRole, "POST", "/rooms/role?user=bob&role=player", owner.AdminToken); w.Code != http.StatusOK {
+		t.Fatalf("revoke: %d %s", w.Code, w.Body)
+	}
+	if w := call(hold, "POST", "/rooms/hold?held=0", mod); w.Code != http.StatusForbidden {
+		t.Errorf("revoked moderator token: %d, want 403", w.Code)
+	}
+
+	events, _ := repos.Audit.List(context.Background(), db.AuditFilter{RoomID: "roles", Action: auditSetRole})
+	if len(events) != 2 || events[0].Actor != "ann" || events[0].ActorKind != db.ActorPlayer {
+		t.Errorf("role audit = %+v", events)
+	}
+}
@@ -1,28 +1,58 @@
 package services
 
 import (
+	"context"
+	"log"
 	"time"
 
 	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
 )
 
-func Cleaner() {
+// playerTimeout is how long a player may miss pings before being dropped.
+const playerTimeout = 60 * time.Second
+
+// Cleaner drops players that stopped pinging. The admin gets grace on top
+// of playerTimeout, so a phone that slept for a moment keeps the room;
+// after that the room is handed to someone else.
+func Cleaner(joins db.JoinRepository, closer *Closer, grace time.Duration) {
 	for {
 		time.Sleep(5 * time.Second)
 		core.Mu.Lock()
 
+		left := map[string][]string{}
+		gone := map[string]string{}
+
 		for id, rm := range core.Rooms {
 			for u, t := range rm.Users {
-				if time.Since(t) > 60*time.Second {
-					delete(rm.Users, u)
-					if u == rm.Admin {
-						delete(core.Rooms, id)
-						break
+				idle := time.Since(t)
+				if u == rm.Admin {
+					if idle > playerTimeout+grace {
+						rm.Leave(u)
+						gone[id] = u
 					}
+					continue
+				}
+				if idle > playerTimeout {
+					rm.Leave(u)
+					left[id] = append(left[id], u)
 				}
 			}
 		}
 
 		core.Mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		for id, users := range left {
+			for _, u := range users {
+				if err := joins.MarkLeft(ctx, id, u); err != nil {
+					log.Println("❌ cleaner mark left:", id, u, err)
+				}
+			}
+		}
+		for id, admin := range gone {
+			closer.HandOff(ctx, id, admin, CloseAdminTimeout)
+		}
+		cancel()
 	}
 }
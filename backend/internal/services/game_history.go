@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"my-source/loto-full/backend/internal/db"
+)
+
+const historyTimeout = 5 * time.Second
+
+// History writes the timeline of each round (draws, claims, result) and
+// the audit of forced draws. Failures are logged and never interrupt the
+// running game.
+type History struct {
+	Games  db.GameRepository
+	Forced db.ForcedDrawRepository
+}
+
+func NewHistory(games db.GameRepository, forced db.ForcedDrawRepository) *History {
+	return &History{Games: games, Forced: forced}
+}
+
+// StartGame opens a games row for a new round. A zero id means history is
+// not recorded for this round; the game itself still runs.
+func (h *History) StartGame(roomID, admin string) int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	id, err := h.Games.Create(ctx, roomID, admin)
+	if err != nil {
+		log.Println("❌ history create game:", roomID, err)
+		return 0
+	}
+	return id
+}
+
+func (h *History) RecordDraw(gameID int64, seq, number int, forced bool) {
+	if gameID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if err := h.Games.InsertDraw(ctx, gameID, seq, number, forced); err != nil {
+		log.Println("❌ history draw:", gameID, err)
+	}
+}
+
+// RecordForce writes the audit row for a force request. Unlike the game
+// timeline this must succeed: without a row the force is refused.
+func (h *History) RecordForce(ctx context.Context, f db.ForcedDrawRecord) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, historyTimeout)
+	defer cancel()
+
+	return h.Forced.Insert(ctx, f)
+}
+
+// ResolveForce closes a force request; seq is only set for ForceDrawn.
+func (h *History) ResolveForce(id int64, status string, seq int) {
+	if id == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if err := h.Forced.Resolve(ctx, id, status, seq); err != nil {
+		log.Println("❌ history force:", id, status, err)
+	}
+}
+
+func (h *History) RecordClaim(gameID int64, user, nums string) {
+	if gameID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if err := h.Games.UpsertClaim(ctx, gameID, user, nums); err != nil {
+		log.Println("❌ history claim:", gameID, err)
+	}
+}
+
+// DismissClaim closes the user's pending claim without a decision, e.g.
+// when the player is kicked.
+func (h *History) DismissClaim(gameID int64, user string) {
+	if gameID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if err := h.Games.ResolveClaim(ctx, gameID, user, db.ClaimDismissed); err != nil {
+		log.Println("❌ history dismiss claim:", gameID, err)
+	}
+}
+
+// EndGame closes a round that stopped without a winner, e.g. because its
+// room closed: claims still queued are dismissed and the game is marked
+// ended, which discloses its forced draws.
+func (h *History) EndGame(gameID int64) {
+	if gameID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if err := h.Games.DismissPendingClaims(ctx, gameID); err != nil {
+		log.Println("❌ history dismiss:", gameID, err)
+	}
+	if err := h.Games.Finish(ctx, gameID, "", ""); err != nil {
+		log.Println("❌ history finish:", gameID, err)
+	}
+}
+
+// RecordClaimResult stores the host's decision. Approving a claim also
+// closes the game and dismisses whatever was still queued.
+func (h *History) RecordClaimResult(gameID int64, user, nums string, approved bool) {
+	if gameID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if !approved {
+		if err := h.Games.ResolveClaim(ctx, gameID, user, db.ClaimRejected); err != nil {
+			log.Println("❌ history reject:", gameID, err)
+		}
+		return
+	}
+
+	if err := h.Games.ResolveClaim(ctx, gameID, user, db.ClaimApproved); err != nil {
+		log.Println("❌ history approve:", gameID, err)
+	}
+	if err := h.Games.DismissPendingClaims(ctx, gameID); err != nil {
+		log.Println("❌ history dismiss:", gameID, err)
+	}
+	if err := h.Games.Finish(ctx, gameID, user, nums); err != nil {
+		log.Println("❌ history finish:", gameID, err)
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"time"
 
 	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
 	"my-source/loto-full/backend/internal/utils"
 )
 
-func GameLoop(rm *core.Room) {
+func GameLoop(rm *core.Room, history *History) {
 	for {
 		time.Sleep(time.Duration(rm.Interval) * time.Second)
 		core.Mu.Lock()
@@ -16,29 +17,40 @@ func GameLoop(rm *core.Room) {
 			core.Mu.Unlock()
 			return
 		}
-		if rm.Paused || len(rm.Numbers) == 0 {
+		if rm.Paused || rm.Held || len(rm.Numbers) == 0 {
 			core.Mu.Unlock()
 			continue
 		}
 
-		if rm.NextForce > 0 && rm.NextForce <= 90 {
-			if !utils.ContainsInt(rm.Called, rm.NextForce) {
-				rm.Current = rm.NextForce
-				rm.Called = append(rm.Called, rm.Current)
-				rm.Numbers = utils.RemoveInt(rm.Numbers, rm.NextForce)
-				core.Mu.Unlock()
-				continue
-			}
-
-			rm.NextForce = 0
+		// a forced number is used once; if it has been drawn meanwhile
+		// the audit row is closed as skipped
+		drawn, forced := false, false
+		force := rm.NextForce
+		forceID, forceStatus, forceSeq := rm.TakeForce(), db.ForceSkipped, 0
+		if force > 0 && force <= 90 && !utils.ContainsInt(rm.Called, force) {
+			rm.Current = force
+			rm.Called = append(rm.Called, rm.Current)
+			rm.Numbers = utils.RemoveInt(rm.Numbers, force)
+			rm.ForcedSeqs = append(rm.ForcedSeqs, len(rm.Called))
+			drawn, forced = true, true
+			forceStatus, forceSeq = db.ForceDrawn, len(rm.Called)
 		}
 
-		if len(rm.Numbers) > 0 {
+		if !drawn && len(rm.Numbers) > 0 {
 			rm.Current = rm.Numbers[0]
 			rm.Numbers = rm.Numbers[1:]
 			rm.Called = append(rm.Called, rm.Current)
+			drawn = true
 		}
 
+		gameID, seq, num := rm.GameID, len(rm.Called), rm.Current
 		core.Mu.Unlock()
+
+		if forceID != 0 {
+			history.ResolveForce(forceID, forceStatus, forceSeq)
+		}
+		if drawn {
+			history.RecordDraw(gameID, seq, num, forced)
+		}
 	}
 }
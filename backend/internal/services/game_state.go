@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+)
+
+const snapshotInterval = 2 * time.Second
+
+// Persister writes every live room to room_states so a restart can pick up
+// running games where they left off.
+type Persister struct {
+	States  db.RoomStateRepository
+	History *History
+
+	mu sync.Mutex
+	// lastSaved holds the last snapshot written or restored per room so
+	// unchanged rooms are not rewritten every tick. Only rooms in it are
+	// ever deleted, so a snapshot that failed to restore is kept.
+	lastSaved map[string][]byte
+}
+
+func NewPersister(states db.RoomStateRepository, history *History) *Persister {
+	return &Persister{
+		States:    states,
+		History:   history,
+		lastSaved: map[string][]byte{},
+	}
+}
+
+// Run snapshots rooms periodically; it never returns.
+func (p *Persister) Run() {
+	for {
+		time.Sleep(snapshotInterval)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		p.SnapshotRooms(ctx)
+		cancel()
+	}
+}
+
+// SnapshotRooms saves changed rooms and drops the snapshots of rooms it
+// saved or restored that have since closed.
+func (p *Persister) SnapshotRooms(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	core.Mu.Lock()
+	snaps := make([]core.RoomSnapshot, 0, len(core.Rooms))
+	for _, rm := range core.Rooms {
+		snaps = append(snaps, rm.Snapshot())
+	}
+	core.Mu.Unlock()
+
+	live := make(map[string]bool, len(snaps))
+	for _, s := range snaps {
+		live[s.ID] = true
+
+		b, err := json.Marshal(s)
+		if err != nil {
+			log.Println("❌ snapshot marshal:", s.ID, err)
+			continue
+		}
+		if bytes.Equal(p.lastSaved[s.ID], b) {
+			continue
+		}
+
+		if err := p.States.Save(ctx, s.ID, b); err != nil {
+			log.Println("❌ snapshot save:", s.ID, err)
+			continue
+		}
+		p.lastSaved[s.ID] = b
+	}
+
+	var closed []string
+	for id := range p.lastSaved {
+		if !live[id] {
+			closed = append(closed, id)
+		}
+	}
+	if len(closed) == 0 {
+		return
+	}
+
+	if err := p.States.Delete(ctx, closed); err != nil {
+		log.Println("❌ snapshot cleanup:", err)
+		return
+	}
+	for _, id := range closed {
+		delete(p.lastSaved, id)
+	}
+}
+
+// RestoreRooms loads persisted rooms into core.Rooms and resumes the game
+// loop of every room that was running. Players get a fresh ping window
+// since nobody could ping while the server was down.
+func (p *Persister) RestoreRooms(ctx context.Context) error {
+	states, err := p.States.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	core.Mu.Lock()
+	defer core.Mu.Unlock()
+
+	for _, st := range states {
+		var s core.RoomSnapshot
+		if err := json.Unmarshal(st.State, &s); err != nil {
+			log.Println("❌ snapshot restore:", st.RoomID, err)
+			continue
+		}
+
+		rm := s.Restore()
+		for u := range rm.Users {
+			rm.Users[u] = now
+		}
+
+		core.Rooms[rm.ID] = rm
+		p.lastSaved[rm.ID] = st.State
+
+		if rm.Running {
+			go GameLoop(rm, p.History)
+		}
+	}
+
+	log.Printf("♻️ Restored %d rooms\n", len(states))
+	return nil
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+)
+
+const CloseErased = "user_erased"
+
+// PersonalData answers data subject requests for a username across the
+// loto database, live rooms and the chat server.
+type PersonalData struct {
+	Repo   db.PersonalDataRepository
+	Closer *Closer
+
+	// ChatURL and AdminSecret reach the chat server's admin endpoints.
+	ChatURL     string
+	AdminSecret string
+
+	client *http.Client
+}
+
+func NewPersonalData(repo db.PersonalDataRepository, closer *Closer, chatURL, adminSecret string) *PersonalData {
+	return &PersonalData{
+		Repo:        repo,
+		Closer:      closer,
+		ChatURL:     strings.TrimRight(chatURL, "/"),
+		AdminSecret: adminSecret,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type UserExport struct {
+	Data *db.PersonalData
+	// Chat is the chat server's export, passed through as-is.
+	Chat json.RawMessage
+}
+
+type ErasureReport struct {
+	Pseudonym   string           `json:"pseudonym"`
+	DB          db.ErasureCounts `json:"db"`
+	LiveRooms   int              `json:"liveRooms"`
+	ClosedRooms []string         `json:"closedRooms"`
+	Chat        json.RawMessage  `json:"chat"`
+}
+
+func (p *PersonalData) Export(ctx context.Context, user string) (*UserExport, error) {
+	data, err := p.Repo.Export(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	chat, err := p.callChat(ctx, http.MethodGet, "/chat/admin/export", user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserExport{Data: data, Chat: chat}, nil
+}
+
+// Erase removes the user from live rooms the way leaving does, releasing
+// their lotos and claims (rooms they host are closed), deletes their chat
+// messages and images, and pseudonymises every database row. Chat goes
+// first so a chat outage leaves nothing half-erased.
+func (p *PersonalData) Erase(ctx context.Context, user string) (*ErasureReport, error) {
+	report := &ErasureReport{Pseudonym: pseudonym(), ClosedRooms: []string{}}
+
+	chat, err := p.callChat(ctx, http.MethodPost, "/chat/admin/erase", user)
+	if err != nil {
+		return nil, err
+	}
+	report.Chat = chat
+
+	// the addresses of the user's bans go with the rows Repo.Erase rewrites
+	data, err := p.Repo.Export(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	bans := map[string][]int64{}
+	for _, b := range data.Bans {
+		if b.Username == user {
+			bans[b.RoomID] = append(bans[b.RoomID], b.ID)
+		}
+	}
+
+	// games whose queue held the user's claims
+	var dismissed []int64
+
+	core.Mu.Lock()
+	for id, rm := range core.Rooms {
+		if rm.Admin == user {
+			report.ClosedRooms = append(report.ClosedRooms, id)
+			continue
+		}
+
+		_, present := rm.Users[user]
+		_, hasRole := rm.Roles[user]
+		touched := present || hasRole || rm.CoHost == user
+		lotos, claims := rm.Quit(user)
+		if len(lotos) > 0 || len(claims) > 0 {
+			touched = true
+		}
+		if len(claims) > 0 {
+			dismissed = append(dismissed, rm.GameID)
+		}
+		if rm.Winner == user {
+			rm.Winner = report.Pseudonym
+			touched = true
+		}
+		if rm.Banned[user] {
+			delete(rm.Banned, user)
+			touched = true
+		}
+		for _, banID := range bans[id] {
+			if _, ok := rm.BannedIPs[banID]; ok {
+				delete(rm.BannedIPs, banID)
+				touched = true
+			}
+		}
+		if _, ok := rm.JoinIPs[user]; ok {
+			delete(rm.JoinIPs, user)
+			touched = true
+		}
+		if _, ok := rm.Kicked[user]; ok {
+			delete(rm.Kicked, user)
+			touched = true
+		}
+
+		if touched {
+			report.LiveRooms++
+		}
+	}
+	core.Mu.Unlock()
+
+	if p.Closer.History != nil {
+		for _, gameID := range dismissed {
+			p.Closer.History.DismissClaim(gameID, user)
+		}
+	}
+	for _, id := range report.ClosedRooms {
+		p.Closer.Close(ctx, id, CloseErased)
+	}
+
+	report.DB, err = p.Repo.Erase(ctx, user, report.Pseudonym)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (p *PersonalData) callChat(ctx context.Context, method, path, user string) (json.RawMessage, error) {
+	if p.ChatURL == "" {
+		return json.RawMessage("null"), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.ChatURL+path+"?user="+url.QueryEscape(user), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.AdminSecret)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("chat server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("chat server: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("chat server: status %d", resp.StatusCode)
+	}
+
+	return json.RawMessage(body), nil
+}
+
+func pseudonym() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return "erased-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/utils"
+)
+
+const (
+	retentionInterval = time.Hour
+	anonymizeBatch    = 500
+)
+
+// anonymizeRules names the report item for each table with client IPs.
+var anonymizeRules = map[db.IPTable]string{
+	db.JoinIPs:       "anonymize_join_ips",
+	db.ForcedDrawIPs: "anonymize_forced_draw_ips",
+	db.AuditIPs:      "anonymize_audit_ips",
+	db.BanIPs:        "anonymize_ban_ips",
+}
+
+// RetentionPolicy says how long personal data is kept. A zero duration
+// disables that rule.
+type RetentionPolicy struct {
+	AnonymizeIPsAfter      time.Duration
+	DeleteJoinsAfter       time.Duration
+	DeleteClosedRoomsAfter time.Duration
+}
+
+type RetentionItem struct {
+	Rule     string    `json:"rule"`
+	Cutoff   time.Time `json:"cutoff"`
+	Affected int64     `json:"affected"`
+}
+
+type RetentionReport struct {
+	DryRun bool            `json:"dryRun"`
+	RanAt  time.Time       `json:"ranAt"`
+	Items  []RetentionItem `json:"items"`
+}
+
+// Retention applies the retention policy to the join and room tables and
+// to every table that records a client IP.
+type Retention struct {
+	Repo   db.RetentionRepository
+	Policy RetentionPolicy
+}
+
+func NewRetention(repo db.RetentionRepository, policy RetentionPolicy) *Retention {
+	return &Retention{Repo: repo, Policy: policy}
+}
+
+// Loop purges on a fixed interval alongside Cleaner; it never returns.
+func (rt *Retention) Loop() {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		report, err := rt.Run(ctx, false)
+		cancel()
+
+		if err != nil {
+			log.Println("❌ retention:", err)
+		}
+		for _, it := range report.Items {
+			if it.Affected > 0 {
+				log.Printf("🧹 RETENTION %s: %d rows\n", it.Rule, it.Affected)
+			}
+		}
+
+		time.Sleep(retentionInterval)
+	}
+}
+
+// Run applies every enabled rule, or with dryRun only reports how many rows
+// each rule would touch.
+func (rt *Retention) Run(ctx context.Context, dryRun bool) (RetentionReport, error) {
+	now := time.Now()
+	report := RetentionReport{DryRun: dryRun, RanAt: now}
+
+	if d := rt.Policy.AnonymizeIPsAfter; d > 0 {
+		cutoff := now.Add(-d)
+		for _, table := range db.IPTables {
+			n, err := rt.anonymizeIPs(ctx, table, cutoff, dryRun)
+			report.Items = append(report.Items, RetentionItem{Rule: anonymizeRules[table], Cutoff: cutoff, Affected: n})
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if d := rt.Policy.DeleteJoinsAfter; d > 0 {
+		cutoff := now.Add(-d)
+		n, err := rt.Repo.DeleteJoinsBefore(ctx, cutoff, dryRun)
+		report.Items = append(report.Items, RetentionItem{Rule: "delete_joins", Cutoff: cutoff, Affected: n})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if d := rt.Policy.DeleteClosedRoomsAfter; d > 0 {
+		cutoff := now.Add(-d)
+		n, err := rt.Repo.DeleteClosedRoomsBefore(ctx, cutoff, dryRun)
+		report.Items = append(report.Items, RetentionItem{Rule: "delete_closed_rooms", Cutoff: cutoff, Affected: n})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (rt *Retention) anonymizeIPs(ctx context.Context, table db.IPTable, cutoff time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		return rt.Repo.CountRowsWithFullIP(ctx, table, cutoff)
+	}
+
+	var total int64
+	for {
+		rows, err := rt.Repo.RowsWithFullIP(ctx, table, cutoff, anonymizeBatch)
+		if err != nil {
+			return total, err
+		}
+
+		for _, row := range rows {
+			if err := rt.Repo.AnonymizeIP(ctx, table, row.ID, utils.AnonymizeIP(row.ClientIP)); err != nil {
+				return total, err
+			}
+			total++
+		}
+
+		if len(rows) < anonymizeBatch {
+			return total, nil
+		}
+	}
+}
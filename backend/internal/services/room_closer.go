@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+)
+
+const (
+	CloseAdminLeft    = "admin_left"
+	CloseAdminTimeout = "admin_timeout"
+	CloseStale        = "stale"
+)
+
+// Closer tears a room down everywhere: the live game and its history, the
+// rooms row, the join sessions and the chat server's messages and images.
+// It also hands rooms over when their admin goes.
+type Closer struct {
+	Rooms   db.RoomRepository
+	Joins   db.JoinRepository
+	Audit   db.AuditRepository
+	History *History
+
+	// ChatURL is the chat server base URL; empty skips the notification.
+	// AdminSecret authorizes the delete on the chat server.
+	ChatURL     string
+	AdminSecret string
+	// PurgeJoins deletes the room's join history instead of keeping it.
+	PurgeJoins bool
+
+	client *http.Client
+}
+
+func NewCloser(rooms db.RoomRepository, joins db.JoinRepository, audit db.AuditRepository, history *History, chatURL, adminSecret string, purgeJoins bool) *Closer {
+	return &Closer{
+		Rooms:       rooms,
+		Joins:       joins,
+		Audit:       audit,
+		History:     history,
+		ChatURL:     strings.TrimRight(chatURL, "/"),
+		AdminSecret: adminSecret,
+		PurgeJoins:  purgeJoins,
+		client:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Close removes the room from core.Rooms, stops its game loop and cleans
+// up everything stored about it. A round still in play is ended in the
+// history and a pending forced number is cancelled. The caller must not
+// hold core.Mu.
+func (c *Closer) Close(ctx context.Context, id, reason string) {
+	var gameID, forceID int64
+	core.Mu.Lock()
+	if rm := core.Rooms[id]; rm != nil {
+		rm.Running = false
+		if !rm.BingoOK {
+			gameID = rm.GameID
+		}
+		forceID = rm.TakeForce()
+		delete(core.Rooms, id)
+	}
+	core.Mu.Unlock()
+
+	c.History.ResolveForce(forceID, db.ForceCancelled, 0)
+	c.History.EndGame(gameID)
+
+	if err := c.Joins.MarkRoomLeft(ctx, id); err != nil {
+		log.Println("❌ close room mark left:", id, err)
+	}
+	if err := c.Rooms.Close(ctx, id, reason); err != nil {
+		log.Println("❌ close room:", id, err)
+	}
+	if c.PurgeJoins {
+		if err := c.Joins.DeleteByRoom(ctx, id); err != nil {
+			log.Println("❌ close room purge joins:", id, err)
+		}
+	}
+
+	c.notifyChat(ctx, id)
+	log.Printf("🚪 ROOM CLOSED %s (%s)\n", id, reason)
+}
+
+// CloseStale closes rooms the database still has open but that are not
+// live, left behind by a crash or a snapshot that did not restore. Run it
+// after restoring rooms.
+func (c *Closer) CloseStale(ctx context.Context) {
+	ids, err := c.Rooms.OpenIDs(ctx)
+	if err != nil {
+		log.Println("❌ list open rooms:", err)
+		return
+	}
+
+	for _, id := range ids {
+		core.Mu.Lock()
+		live := core.Rooms[id] != nil
+		core.Mu.Unlock()
+
+		if !live {
+			c.Close(ctx, id, CloseStale)
+		}
+	}
+}
+
+func (c *Closer) notifyChat(ctx context.Context, id string) {
+	if c.ChatURL == "" {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodDelete,
+		c.ChatURL+"/chat/room?room="+url.QueryEscape(id),
+		nil,
+	)
+	if err != nil {
+		log.Println("❌ chat room delete req:", id, err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+c.AdminSecret)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		log.Println("❌ chat room delete:", id, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("❌ chat room delete %s: status %d\n", id, resp.StatusCode)
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"my-source/loto-full/backend/internal/core"
+	"my-source/loto-full/backend/internal/db"
+	"my-source/loto-full/backend/internal/utils"
+)
+
+// auditHandOff is the audit action of an automatic hand-off; explicit
+// transfers are recorded by the handler.
+const auditHandOff = "room.handoff"
+
+// HandOff passes the room from admin, who must already be out of its
+// player list, to the co-host or the longest-present player. With nobody
+// left the room is closed for reason instead. It returns the new admin,
+// empty if the room was closed or had already changed hands.
+// The caller must not hold core.Mu.
+func (c *Closer) HandOff(ctx context.Context, id, admin, reason string) string {
+	core.Mu.Lock()
+	rm := core.Rooms[id]
+	if rm == nil || rm.Admin != admin {
+		core.Mu.Unlock()
+		return ""
+	}
+	next := rm.Successor()
+	if next != "" {
+		rm.HandOff(next, utils.RandomKey())
+	}
+	core.Mu.Unlock()
+
+	if next == "" {
+		c.Close(ctx, id, reason)
+		return ""
+	}
+
+	if err := c.Joins.MarkLeft(ctx, id, admin); err != nil {
+		log.Println("❌ hand-off mark left:", id, admin, err)
+	}
+	if err := c.Rooms.SetAdmin(ctx, id, next); err != nil {
+		log.Println("❌ hand-off set admin:", id, err)
+	}
+
+	before, _ := json.Marshal(map[string]string{"admin": admin})
+	after, _ := json.Marshal(map[string]string{"admin": next, "reason": reason})
+	if err := c.Audit.Insert(ctx, db.AuditEventRecord{
+		Action:    auditHandOff,
+		Actor:     "system",
+		ActorKind: db.ActorSystem,
+		RoomID:    id,
+		Before:    before,
+		After:     after,
+	}); err != nil {
+		log.Println("❌ audit:", auditHandOff, id, err)
+	}
+
+	log.Printf("👑 ROOM %s HANDED FROM %s TO %s (%s)\n", id, admin, next, reason)
+	return next
+}
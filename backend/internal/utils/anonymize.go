@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"net"
+	"strings"
+)
+
+// AnonymizeIP keeps the network part of an address (/24 for IPv4, /48 for
+// IPv6) and drops the host. Values that are not IPs become "".
+func AnonymizeIP(raw string) string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		ip := net.ParseIP(strings.TrimSpace(part))
+		if ip == nil {
+			continue
+		}
+
+		if v4 := ip.To4(); v4 != nil {
+			out = append(out, v4.Mask(net.CIDRMask(24, 32)).String())
+		} else {
+			out = append(out, ip.Mask(net.CIDRMask(48, 128)).String())
+		}
+	}
+
+	return strings.Join(out, ", ")
+}
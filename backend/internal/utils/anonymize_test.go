@@ -0,0 +1,19 @@
+package utils
+
+import "testing"
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.77":           "203.0.113.0",
+		"2001:db8:1234:5678::1":  "2001:db8:1234::",
+		"203.0.113.77, 10.1.2.3": "203.0.113.0, 10.1.2.0",
+		"::ffff:198.51.100.9":    "198.51.100.0",
+		"not an ip":              "",
+		"":                       "",
+	}
+	for in, want := range tests {
+		if got := AnonymizeIP(in); got != want {
+			t.Errorf("AnonymizeIP(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
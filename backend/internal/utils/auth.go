@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BearerToken returns the token from an "Authorization: Bearer ..." header.
+func BearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
@@ -0,0 +1,10 @@
+package utils
+
+import "testing"
+
+func TestRandomKey(t *testing.T) {
+	a, b := RandomKey(), RandomKey()
+	if len(a) != 32 || a == b {
+		t.Errorf("RandomKey = %q, %q", a, b)
+	}
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxSecretLen is the longest room secret bcrypt can hash.
+const MaxSecretLen = 72
+
+// HashSecret returns a salted bcrypt hash of a room secret.
+func HashSecret(secret string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(h), nil
+}
+
+// CheckSecret reports whether secret matches hash. bcrypt compares in
+// constant time; an empty or malformed hash never matches.
+func CheckSecret(hash, secret string) bool {
+	if !strings.HasPrefix(hash, "$2") {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret)) == nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/* ===================== BLOB STORE ===================== */
+
+var errBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the bytes of uploaded images. Keys are slash separated
+// ("images/<id>", "thumbs/<id>"); metadata lives in the MessageStore.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+/* ===================== BLOB STORE (FS) ===================== */
+
+type fsBlobStore struct {
+	dir string
+}
+
+func newFSBlobStore(dir string) (*fsBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fsBlobStore{dir: dir}, nil
+}
+
+func (s *fsBlobStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+		return "", errors.New("invalid blob key: " + key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
+
+// Put writes to a temp file and renames it, so readers never see a
+// half-written image.
+func (s *fsBlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *fsBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errBlobNotFound
+	}
+	return data, err
+}
+
+func (s *fsBlobStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// openBlobStore picks the image backend: CHAT_BLOB_STORE=s3 uses an
+// S3-compatible bucket, anything else the local directory CHAT_BLOB_DIR.
+func openBlobStore() BlobStore {
+	if os.Getenv("CHAT_BLOB_STORE") == "s3" {
+		s, err := newS3BlobStore(s3ConfigFromEnv())
+		if err != nil {
+			log.Fatal("chat blob store: ", err)
+		}
+		log.Println("✅ Chat images stored in S3 bucket", s.bucket)
+		return s
+	}
+
+	dir := os.Getenv("CHAT_BLOB_DIR")
+	if dir == "" {
+		dir = "./data/chat-images"
+	}
+
+	s, err := newFSBlobStore(dir)
+	if err != nil {
+		log.Fatal("chat blob store: ", err)
+	}
+	log.Println("✅ Chat images stored in", dir)
+	return s
+}
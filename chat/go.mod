@@ -2,4 +2,11 @@ module my-source/loto-full/backend/chat
 
 go 1.25.3
 
-require github.com/lib/pq v1.10.9
+require (
+	github.com/lib/pq v1.10.9
+	my-source/loto-full/shared v0.0.0
+)
+
+require golang.org/x/image v0.25.0
+
+replace my-source/loto-full/shared => ../shared
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+/* ===================== IMAGE PROCESSING ===================== */
+
+const (
+	// maxImageBytes caps the size of a single upload.
+	maxImageBytes = 5 * 1024 * 1024
+
+	// maxImageSide and maxImagePixels are checked from the header before
+	// decoding, so a small file cannot expand into a huge bitmap.
+	maxImageSide   = 8192
+	maxImagePixels = 40_000_000
+
+	// thumbSize bounds the longer side of a generated thumbnail.
+	thumbSize = 320
+)
+
+var (
+	errUnsupportedImage = errors.New("unsupported image")
+	errImageTooLarge    = errors.New("image dimensions too large")
+)
+
+// sniffImage identifies an upload by its magic bytes; the client's
+// declared content type is never trusted.
+func sniffImage(head []byte) (format string, ok bool) {
+	switch {
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "png", true
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg", true
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "gif", true
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return "webp", true
+	}
+	return "", false
+}
+
+func decodeConfig(format string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "png":
+		return png.DecodeConfig(r)
+	case "jpeg":
+		return jpeg.DecodeConfig(r)
+	case "gif":
+		return gif.DecodeConfig(r)
+	case "webp":
+		return webp.DecodeConfig(r)
+	}
+	return image.Config{}, errUnsupportedImage
+}
+
+func decodeImage(format string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "png":
+		return png.Decode(r)
+	case "jpeg":
+		return jpeg.Decode(r)
+	case "webp":
+		return webp.Decode(r)
+	}
+	return nil, errUnsupportedImage
+}
+
+// processedImage is an upload after re-encoding. Re-encoding drops every
+// metadata block (EXIF, XMP, comments), so nothing but pixels is kept.
+type processedImage struct {
+	Data      []byte
+	Mime      string
+	Thumb     []byte
+	ThumbMime string
+	Width     int
+	Height    int
+}
+
+// processImage decodes an upload, applies its EXIF orientation, strips
+// metadata by re-encoding and renders a thumbnail.
+//
+// WebP cannot be written back, so it is stored as JPEG when opaque and
+// PNG otherwise.
+func processImage(data []byte) (*processedImage, error) {
+	format, ok := sniffImage(data)
+	if !ok {
+		return nil, errUnsupportedImage
+	}
+
+	cfg, err := decodeConfig(format, data)
+	if err != nil {
+		return nil, errUnsupportedImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, errUnsupportedImage
+	}
+	if cfg.Width > maxImageSide || cfg.Height > maxImageSide ||
+		cfg.Width*cfg.Height > maxImagePixels {
+		return nil, errImageTooLarge
+	}
+
+	if format == "gif" {
+		return processGIF(data)
+	}
+
+	img, err := decodeImage(format, data)
+	if err != nil {
+		return nil, errUnsupportedImage
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	photo := format == "jpeg" || (format == "webp" && isOpaque(img))
+
+	out := &processedImage{}
+	var buf bytes.Buffer
+	if photo {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+		out.Mime = "image/jpeg"
+	} else {
+		err = png.Encode(&buf, img)
+		out.Mime = "image/png"
+	}
+	if err != nil {
+		return nil, err
+	}
+	out.Data = buf.Bytes()
+
+	b := img.Bounds()
+	out.Width, out.Height = b.Dx(), b.Dy()
+
+	out.Thumb, out.ThumbMime, err = encodeThumb(img, photo)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// gifPixels walks the block structure of a GIF without decoding it and
+// returns the area of all its frames together, which is what DecodeAll
+// allocates.
+func gifPixels(data []byte) (int, error) {
+	// header and logical screen descriptor
+	if len(data) < 13 {
+		return 0, errUnsupportedImage
+	}
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << ((flags & 7) + 1)
+	}
+
+	// skipSubBlocks steps over a chain of length-prefixed blocks.
+	skipSubBlocks := func() bool {
+		for pos < len(data) {
+			n := int(data[pos])
+			pos += 1 + n
+			if n == 0 {
+				return pos <= len(data)
+			}
+		}
+		return false
+	}
+
+	total := 0
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension: label, then sub-blocks
+			pos += 2
+			if !skipSubBlocks() {
+				return 0, errUnsupportedImage
+			}
+
+		case 0x2C: // image descriptor
+			if pos+10 > len(data) {
+				return 0, errUnsupportedImage
+			}
+			w := int(binary.LittleEndian.Uint16(data[pos+5:]))
+			h := int(binary.LittleEndian.Uint16(data[pos+7:]))
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << ((flags & 7) + 1)
+			}
+			pos++ // LZW minimum code size
+			if !skipSubBlocks() {
+				return 0, errUnsupportedImage
+			}
+
+			total += w * h
+			if total > maxImagePixels {
+				return 0, errImageTooLarge
+			}
+
+		case 0x3B: // trailer
+			return total, nil
+
+		default:
+			return 0, errUnsupportedImage
+		}
+	}
+	return total, nil
+}
+
+// processGIF keeps animation: every frame is decoded and written back,
+// which drops comment and application extensions other than looping.
+// Frames are counted before decoding so many small frames cannot add up
+// to more than maxImagePixels.
+func processGIF(data []byte) (*processedImage, error) {
+	if _, err := gifPixels(data); err != nil {
+		return nil, err
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(g.Image) == 0 {
+		return nil, errUnsupportedImage
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, err
+	}
+
+	out := &processedImage{
+		Data:   buf.Bytes(),
+		Mime:   "image/gif",
+		Width:  g.Config.Width,
+		Height: g.Config.Height,
+	}
+
+	// the thumbnail is a still of the first frame
+	first := image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	draw.Draw(first, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Over)
+
+	out.Thumb, out.ThumbMime, err = encodeThumb(first, false)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// encodeThumb scales img to fit thumbSize. Photos become JPEG, anything
+// that may carry transparency stays PNG.
+func encodeThumb(img image.Image, photo bool) ([]byte, string, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > thumbSize || h > thumbSize {
+		if w >= h {
+			w, h = thumbSize, max(1, h*thumbSize/w)
+		} else {
+			w, h = max(1, w*thumbSize/h), thumbSize
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+
+	var buf bytes.Buffer
+	if photo {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+/* ===================== EXIF ORIENTATION ===================== */
+
+// jpegOrientation reads the EXIF orientation tag (1-8) from a JPEG, or
+// returns 1 when there is none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			return 1
+		}
+
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+size]
+
+		if marker == 0xE1 && len(seg) > 6 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(t []byte) int {
+	if len(t) < 8 {
+		return 1
+	}
+
+	var bo binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(bo.Uint32(t[4:]))
+	if ifd+2 > len(t) {
+		return 1
+	}
+
+	n := int(bo.Uint16(t[ifd:]))
+	for k := 0; k < n; k++ {
+		e := ifd + 2 + k*12
+		if e+12 > len(t) {
+			return 1
+		}
+		if bo.Uint16(t[e:]) == 0x0112 {
+			v := int(bo.Uint16(t[e+8:]))
+			if v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation returns img as it should be displayed for the given
+// EXIF orientation value.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // upside down
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				dx, dy = x, h-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // transverse
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotate 90° counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestSniffImage(t *testing.T) {
+	tests := []struct {
+		head []byte
+		want string
+	}{
+		{[]byte("\x89PNG\r\n\x1a\n...."), "png"},
+		{[]byte{0xFF, 0xD8, 0xFF, 0xE0}, "jpeg"},
+		{[]byte("GIF89a...."), "gif"},
+		{[]byte("GIF87a...."), "gif"},
+		{[]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "webp"},
+		{[]byte("RIFF\x00\x00\x00\x00WAVEfmt "), ""},
+		{[]byte("<svg xmlns="), ""},
+		{[]byte{0xFF, 0xD8}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		got, ok := sniffImage(tt.head)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("sniffImage(%q) = %q, %v; want %q", tt.head, got, ok, tt.want)
+		}
+	}
+}
+
+// withOrientation inserts an EXIF APP1 segment carrying the orientation
+// tag right after the JPEG's SOI marker.
+func withOrientation(t *testing.T, jpg []byte, bo binary.ByteOrder, orientation uint16) []byte {
+	t.Helper()
+
+	tiff := make([]byte, 8+2+12+4)
+	if bo == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	bo.PutUint16(tiff[2:], 42)
+	bo.PutUint32(tiff[4:], 8)
+	bo.PutUint16(tiff[8:], 1)
+	entry := tiff[10:]
+	bo.PutUint16(entry[0:], 0x0112)
+	bo.PutUint16(entry[2:], 3) // SHORT
+	bo.PutUint32(entry[4:], 1)
+	bo.PutUint16(entry[8:], orientation)
+
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(seg)+2))
+	app1 = append(app1, seg...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, app1...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	plain := encodeJPEG(t, 4, 2)
+	if got := jpegOrientation(plain); got != 1 {
+		t.Errorf("no EXIF: orientation %d, want 1", got)
+	}
+
+	for _, bo := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, o := range []uint16{3, 6, 8} {
+			if got := jpegOrientation(withOrientation(t, plain, bo, o)); got != int(o) {
+				t.Errorf("%v orientation %d: read %d", bo, o, got)
+			}
+		}
+	}
+
+	if got := jpegOrientation(withOrientation(t, plain, binary.BigEndian, 9)); got != 1 {
+		t.Errorf("out of range orientation: read %d, want 1", got)
+	}
+
+	// a segment length past the end of the data must not panic
+	broken := append([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF}, "Exif\x00\x00"...)
+	if got := jpegOrientation(broken); got != 1 {
+		t.Errorf("truncated segment: read %d, want 1", got)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1: red then blue
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		// where red ends up
+		rx, ry int
+	}{
+		{1, 2, 1, 0, 0},
+		{2, 2, 1, 1, 0},
+		{3, 2, 1, 1, 0},
+		{4, 2, 1, 0, 0},
+		{5, 1, 2, 0, 0},
+		{6, 1, 2, 0, 0},
+		{7, 1, 2, 0, 1},
+		{8, 1, 2, 0, 1},
+	}
+	for _, tt := range tests {
+		got := applyOrientation(src, tt.orientation)
+		b := got.Bounds()
+		if b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.w, tt.h)
+			continue
+		}
+		if c := color.NRGBAModel.Convert(got.At(tt.rx, tt.ry)); c != red {
+			t.Errorf("orientation %d: pixel (%d,%d) = %v, want red", tt.orientation, tt.rx, tt.ry, c)
+		}
+	}
+}
+
+func TestProcessImageRotatesAndStripsEXIF(t *testing.T) {
+	data := withOrientation(t, encodeJPEG(t, 40, 20), binary.BigEndian, 6)
+
+	out, err := processImage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Width != 20 || out.Height != 40 {
+		t.Errorf("size %dx%d, want 20x40", out.Width, out.Height)
+	}
+	if out.Mime != "image/jpeg" || bytes.Contains(out.Data, []byte("Exif")) {
+		t.Errorf("output %s still carries EXIF: %v", out.Mime, bytes.Contains(out.Data, []byte("Exif")))
+	}
+	if len(out.Thumb) == 0 {
+		t.Error("no thumbnail")
+	}
+}
+
+func TestProcessImageRejects(t *testing.T) {
+	if _, err := processImage([]byte("<svg></svg>")); !errors.Is(err, errUnsupportedImage) {
+		t.Errorf("svg: err = %v", err)
+	}
+
+	// a PNG header claiming a huge canvas is refused before decoding
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	huge := buf.Bytes()
+	binary.BigEndian.PutUint32(huge[16:], 9000)
+	binary.BigEndian.PutUint32(huge[20:], 9000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	if _, err := processImage(huge); !errors.Is(err, errImageTooLarge) {
+		t.Errorf("9000x9000 png: err = %v, want errImageTooLarge", err)
+	}
+}
+
+func TestGIFPixels(t *testing.T) {
+	pal := color.Palette{color.Black, color.White}
+	g := &gif.GIF{}
+	for i := 0; i < 3; i++ {
+		g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, 10, 10), pal))
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := gifPixels(buf.Bytes())
+	if err != nil || n != 300 {
+		t.Errorf("3 frames of 10x10: %d, %v; want 300", n, err)
+	}
+	if _, err := processImage(buf.Bytes()); err != nil {
+		t.Errorf("processImage: %v", err)
+	}
+}
+
+func TestGIFPixelsManyFrames(t *testing.T) {
+	// a 1x1 screen whose empty frames each claim 5000x5000
+	data := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00")
+	frame := []byte{0x2C, 0, 0, 0, 0, 0x88, 0x13, 0x88, 0x13, 0, 2, 0}
+	for i := 0; i < 2; i++ {
+		data = append(data, frame...)
+	}
+	data = append(data, 0x3B)
+
+	if _, err := gifPixels(data); !errors.Is(err, errImageTooLarge) {
+		t.Errorf("2 frames of 5000x5000: err = %v, want errImageTooLarge", err)
+	}
+	if _, err := processImage(data); !errors.Is(err, errImageTooLarge) {
+		t.Errorf("processImage: err = %v, want errImageTooLarge", err)
+	}
+
+	if _, err := gifPixels(data[:20]); !errors.Is(err, errUnsupportedImage) {
+		t.Errorf("truncated: err = %v, want errUnsupportedImage", err)
+	}
+}
@@ -1,20 +1,28 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
+
+	"my-source/loto-full/shared/web"
 )
 
 /* ===================== MODELS ===================== */
 
 type ChatMessage struct {
+	ID   int64  `json:"id"`
 	Room string `json:"room"`
 	User string `json:"user"`
 	Type string `json:"type"` // text | image
@@ -22,275 +30,595 @@ type ChatMessage struct {
 	Ts   int64  `json:"ts"`
 }
 
+// ChatImage is the metadata of an uploaded image; the bytes live in the
+// blob store under imageKey and thumbKey.
 type ChatImage struct {
-	Data []byte
-	Mime string
-	Time int64
+	ID        string
+	MessageID int64
+	Room      string
+	User      string
+	Mime      string
+	ThumbMime string
+	Width     int
+	Height    int
+	Time      int64
 }
 
-/* ===================== STORAGE (MEM) ===================== */
+/* ===================== STORAGE ===================== */
 
 var (
-	mu    sync.Mutex
-	rooms = make(map[string][]ChatMessage)
-
-	imgMu      sync.Mutex
-	chatImages = make(map[string]ChatImage)
+	store MessageStore
+	blobs BlobStore
 )
 
-/* ===================== CORS ===================== */
+/* ===================== CHAT TEXT ===================== */
 
-func withCORS(h http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,DELETE,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		if r.Method == "OPTIONS" {
-			return
-		}
-		h(w, r)
+func sendChat(w http.ResponseWriter, r *http.Request) {
+	var msg ChatMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
-}
 
-/* ===================== /doi COMMAND ===================== */
+	member := memberFrom(r)
+	msg.Room, msg.User = member.Room, member.User
 
-func parseDoiCommand(text string) (secret string, num int, ok bool) {
-	if !strings.HasPrefix(text, "/doi ") {
-		return
-	}
+	msg.Type = "text"
 
-	parts := strings.Fields(text)
-	if len(parts) != 3 {
+	if _, err := store.Append(r.Context(), msg); err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	secret = parts[1]
+	log.Printf("💬 TEXT [%s] %s: %s\n", msg.Room, msg.User, msg.Text)
+	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+}
 
-	n, err := strconv.Atoi(parts[2])
-	if err != nil {
-		return
-	}
+/* ===================== CHAT IMAGE ===================== */
 
-	return secret, n, true
+type ImagePayload struct {
+	Base64 string `json:"base64"`
 }
 
-func callForceNumber(room string, secret string, num int) {
-	payload := map[string]any{
-		"id":     room,
-		"num":    num,
-		"secret": secret,
-	}
+// sendImage is the original JSON upload taking a base64 data URL. The
+// body is capped before decoding; the prefix's MIME type is ignored.
+func sendImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageBytes*4/3+4096)
 
-	b, _ := json.Marshal(payload)
+	var p ImagePayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			http.Error(w, "image too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
-	req, err := http.NewRequest(
-		"POST",
-		"http://localhost:8080/rooms/force-number",
-		bytes.NewBuffer(b),
-	)
-	if err != nil {
-		log.Println("❌ force-number req error:", err)
+	if !strings.Contains(p.Base64, ",") {
+		http.Error(w, "invalid image", 400)
 		return
 	}
 
-	req.Header.Set("Content-Type", "text/plain")
+	data := strings.SplitN(p.Base64, ",", 2)[1]
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
+	bytesData, err := base64.StdEncoding.DecodeString(data)
 	if err != nil {
-		log.Println("❌ force-number call error:", err)
+		http.Error(w, "decode error", 400)
 		return
 	}
-	defer resp.Body.Close()
 
-	log.Printf("🎯 FORCE-NUMBER room=%s num=%d status=%d\n",
-		room, num, resp.StatusCode)
-}
+	if len(bytesData) > maxImageBytes {
+		http.Error(w, "image too large", http.StatusRequestEntityTooLarge)
+		return
+	}
 
-/* ===================== CHAT TEXT ===================== */
+	postImage(w, r, bytesData)
+}
 
-func sendChat(w http.ResponseWriter, r *http.Request) {
-	var msg ChatMessage
-	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+// uploadImage takes a multipart form with a "file" field. Parts are
+// streamed, and the file is read up to the size cap without buffering the
+// whole request first.
+func uploadImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// 🔥 CHECK /doi → chỉ execute, KHÔNG lưu chat
-	if secret, num, ok := parseDoiCommand(msg.Text); ok {
-		go callForceNumber(msg.Room, secret, num)
-
-		log.Printf("⚡ /doi CMD room=%s by=%s num=%d\n",
-			msg.Room, msg.User, num)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageBytes+64*1024)
 
-		json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "multipart form expected", 400)
 		return
 	}
 
-	// ===== NORMAL CHAT =====
-	msg.Type = "text"
-	msg.Ts = time.Now().Unix()
+	var data []byte
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			uploadError(w, err)
+			return
+		}
 
-	mu.Lock()
-	list := rooms[msg.Room]
-	list = append(list, msg)
-	if len(list) > 50 {
-		list = list[len(list)-50:]
+		if part.FormName() == "file" {
+			data, err = io.ReadAll(io.LimitReader(part, maxImageBytes+1))
+			if err != nil {
+				uploadError(w, err)
+				return
+			}
+			if len(data) > maxImageBytes {
+				http.Error(w, "image too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+		}
+		part.Close()
 	}
-	rooms[msg.Room] = list
-	mu.Unlock()
 
-	log.Printf("💬 TEXT [%s] %s: %s\n", msg.Room, msg.User, msg.Text)
-	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
-}
+	if len(data) == 0 {
+		http.Error(w, "file is required", 400)
+		return
+	}
 
-/* ===================== CHAT IMAGE ===================== */
+	postImage(w, r, data)
+}
 
-type ImagePayload struct {
-	Room   string `json:"room"`
-	User   string `json:"user"`
-	Base64 string `json:"base64"`
+func uploadError(w http.ResponseWriter, err error) {
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		http.Error(w, "image too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, "invalid multipart body", 400)
 }
 
-func sendImage(w http.ResponseWriter, r *http.Request) {
-	var p ImagePayload
-	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+func postImage(w http.ResponseWriter, r *http.Request, data []byte) {
+	member := memberFrom(r)
+
+	if _, ok := sniffImage(data); !ok {
+		http.Error(w, "not a PNG, JPEG, GIF or WebP image", http.StatusUnsupportedMediaType)
 		return
 	}
 
-	if !strings.Contains(p.Base64, ",") {
+	msg, err := storeImage(r.Context(), member.Room, member.User, data)
+	if errors.Is(err, errUnsupportedImage) {
 		http.Error(w, "invalid image", 400)
 		return
 	}
-
-	parts := strings.SplitN(p.Base64, ",", 2)
-	meta := parts[0]
-	data := parts[1]
-
-	mime := "image/png"
-	if strings.Contains(meta, "image/jpeg") {
-		mime = "image/jpeg"
+	if errors.Is(err, errImageTooLarge) {
+		http.Error(w, "image dimensions too large", http.StatusRequestEntityTooLarge)
+		return
 	}
-
-	bytesData, err := base64.StdEncoding.DecodeString(data)
 	if err != nil {
-		http.Error(w, "decode error", 400)
+		log.Println("❌ chat image:", err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	if len(bytesData) > 5*1024*1024 {
-		http.Error(w, "image too large", 400)
-		return
+	log.Printf("🖼️ IMAGE [%s] %s\n", member.Room, member.User)
+	json.NewEncoder(w).Encode(map[string]string{
+		"url":   msg.Text,
+		"thumb": msg.Text + "?thumb=1",
+	})
+}
+
+// storeImage cleans the upload, writes the image and its thumbnail to the
+// blob store and posts the chat message that owns them.
+func storeImage(ctx context.Context, room, user string, data []byte) (ChatMessage, error) {
+	img, err := processImage(data)
+	if err != nil {
+		return ChatMessage{}, err
 	}
 
-	id := time.Now().Format("20060102150405") + "_" + p.User
+	id, err := newImageID()
+	if err != nil {
+		return ChatMessage{}, err
+	}
 
-	imgMu.Lock()
-	chatImages[id] = ChatImage{
-		Data: bytesData,
-		Mime: mime,
-		Time: time.Now().Unix(),
+	if err := blobs.Put(ctx, imageKey(id), img.Data, img.Mime); err != nil {
+		return ChatMessage{}, err
+	}
+	if err := blobs.Put(ctx, thumbKey(id), img.Thumb, img.ThumbMime); err != nil {
+		blobs.Delete(ctx, imageKey(id))
+		return ChatMessage{}, err
 	}
-	imgMu.Unlock()
 
-	msg := ChatMessage{
-		Room: p.Room,
-		User: p.User,
+	msg, err := store.Append(ctx, ChatMessage{
+		Room: room,
+		User: user,
 		Type: "image",
 		Text: "/chat/image/" + id,
-		Ts:   time.Now().Unix(),
+	})
+	if err != nil {
+		blobs.Delete(ctx, imageKey(id))
+		blobs.Delete(ctx, thumbKey(id))
+		return ChatMessage{}, err
 	}
 
-	mu.Lock()
-	rooms[p.Room] = append(rooms[p.Room], msg)
-	mu.Unlock()
+	// the message exists from here on; if this insert fails the blobs are
+	// unreachable and only cost disk until the next manual cleanup
+	err = store.AddImage(ctx, ChatImage{
+		ID:        id,
+		MessageID: msg.ID,
+		Room:      room,
+		User:      user,
+		Mime:      img.Mime,
+		ThumbMime: img.ThumbMime,
+		Width:     img.Width,
+		Height:    img.Height,
+		Time:      msg.Ts,
+	})
+	return msg, err
+}
 
-	log.Printf("🖼️ IMAGE [%s] %s\n", p.Room, p.User)
-	json.NewEncoder(w).Encode(map[string]string{"url": msg.Text})
+func newImageID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
+func imageKey(id string) string { return "images/" + id }
+func thumbKey(id string) string { return "thumbs/" + id }
+
 /* ===================== SERVE IMAGE ===================== */
 
+// serveImage returns the cleaned image, or its thumbnail with ?thumb=1.
+// Only members of the image's room can see it.
 func serveImage(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/chat/image/")
 
-	imgMu.Lock()
-	img, ok := chatImages[id]
-	imgMu.Unlock()
+	img, err := store.GetImage(r.Context(), id)
+	if errors.Is(err, errImageNotFound) || (err == nil && img.Room != memberFrom(r).Room) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	key, mime := imageKey(id), img.Mime
+	if r.URL.Query().Get("thumb") == "1" {
+		key, mime = thumbKey(id), img.ThumbMime
+	}
 
-	if !ok {
+	data, err := blobs.Get(r.Context(), key)
+	if errors.Is(err, errBlobNotFound) {
 		http.NotFound(w, r)
 		return
 	}
+	if err != nil {
+		log.Println("❌ chat blob:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-	w.Header().Set("Content-Type", img.Mime)
-	w.Write(img.Data)
+	// content never changes, but access is per member, so only the
+	// browser may keep a copy
+	w.Header().Set("Content-Type", mime)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
 }
 
 /* ===================== LIST CHAT ===================== */
 
+// listChat returns one page of the member's room history, oldest first.
+// Without "before" it is the latest page; pass the smallest id seen to go
+// back.
 func listChat(w http.ResponseWriter, r *http.Request) {
-	room := r.URL.Query().Get("room")
-	if room == "" {
-		json.NewEncoder(w).Encode([]ChatMessage{})
+	q := r.URL.Query()
+	room := memberFrom(r).Room
+	if q.Get("room") != "" && q.Get("room") != room {
+		http.Error(w, "forbidden", http.StatusForbidden)
 		return
 	}
 
-	mu.Lock()
-	list := rooms[room]
-	mu.Unlock()
+	before, _ := strconv.ParseInt(q.Get("before"), 10, 64)
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	list, err := store.List(r.Context(), room, before, limit)
+	if err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
 	json.NewEncoder(w).Encode(list)
 }
 
+/* ===================== MODERATION ===================== */
+
+// deleteMessage removes ?id= from the member's room. Only tokens the loto
+// API issued to the room's owner or a moderator may do this.
+func deleteMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	member := memberFrom(r)
+	if !member.Mod {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = store.DeleteMessage(r.Context(), member.Room, id)
+	if errors.Is(err, errMessageNotFound) {
+		http.Error(w, "message not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// an image message leaves its image orphaned
+	sweepImages(r.Context())
+
+	log.Printf("🧹 CHAT MESSAGE DELETED [%s] #%d by %s\n", member.Room, id, member.User)
+	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+}
+
 /* ===================== DELETE ROOM ===================== */
 
 func deleteRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
 	room := r.URL.Query().Get("room")
 	if room == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	mu.Lock()
-	delete(rooms, room)
-	mu.Unlock()
+	if err := store.DeleteRoom(r.Context(), room); err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sweepImages(r.Context())
 
 	log.Printf("🗑️ CHAT ROOM DESTROYED: %s\n", room)
 	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
 }
 
+/* ===================== ADMIN: PERSONAL DATA ===================== */
+
+var adminSecret = os.Getenv("ADMIN_SECRET")
+
+func adminOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if adminSecret == "" || token == auth ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(adminSecret)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+type ExportedImage struct {
+	ID   string `json:"id"`
+	Room string `json:"room"`
+	Mime string `json:"mime"`
+	Ts   int64  `json:"ts"`
+	Data string `json:"data"` // data URL
+}
+
+type UserExport struct {
+	User     string          `json:"user"`
+	Messages []ChatMessage   `json:"messages"`
+	Images   []ExportedImage `json:"images"`
+}
+
+func exportUser(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	messages, err := store.ListByUser(r.Context(), user)
+	if err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	images, err := store.ImagesByUser(r.Context(), user)
+	if err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res := UserExport{User: user, Messages: messages, Images: []ExportedImage{}}
+
+	for _, img := range images {
+		data, err := blobs.Get(r.Context(), imageKey(img.ID))
+		if errors.Is(err, errBlobNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Println("❌ chat blob:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		res.Images = append(res.Images, ExportedImage{
+			ID:   img.ID,
+			Room: img.Room,
+			Mime: img.Mime,
+			Ts:   img.Time,
+			Data: "data:" + img.Mime + ";base64," + base64.StdEncoding.EncodeToString(data),
+		})
+	}
+
+	json.NewEncoder(w).Encode(res)
+}
+
+func rateLimitMetrics(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(web.RateLimitStats())
+}
+
+func eraseUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	owned, err := store.ImagesByUser(r.Context(), user)
+	if err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	messages, err := store.DeleteByUser(r.Context(), user)
+	if err != nil {
+		log.Println("❌ chat store:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// the user's images lost their messages above; remove them right away
+	// instead of waiting for the next sweep
+	sweepImages(r.Context())
+	images := len(owned)
+
+	// the name is what was erased; keep it out of the log too
+	log.Printf("🧽 ERASED messages=%d images=%d\n", messages, images)
+	json.NewEncoder(w).Encode(map[string]int{"messages": messages, "images": images})
+}
+
 /* ===================== CLEANUP ===================== */
 
+// sweepImages deletes images whose message no longer exists, blobs first
+// so a failure leaves the row behind to be retried.
+func sweepImages(ctx context.Context) int {
+	removed := 0
+	for {
+		orphans, err := store.OrphanImages(ctx, 100)
+		if err != nil {
+			log.Println("❌ image sweep:", err)
+			return removed
+		}
+		if len(orphans) == 0 {
+			return removed
+		}
+
+		for _, img := range orphans {
+			if err := blobs.Delete(ctx, imageKey(img.ID)); err != nil {
+				log.Println("❌ image sweep:", err)
+				return removed
+			}
+			if err := blobs.Delete(ctx, thumbKey(img.ID)); err != nil {
+				log.Println("❌ image sweep:", err)
+				return removed
+			}
+			if err := store.DeleteImage(ctx, img.ID); err != nil {
+				log.Println("❌ image sweep:", err)
+				return removed
+			}
+			removed++
+		}
+	}
+}
+
 func startCleanup() {
 	go func() {
 		for {
 			time.Sleep(1 * time.Minute)
-			now := time.Now().Unix()
-
-			imgMu.Lock()
-			for id, img := range chatImages {
-				if now-img.Time > 600 {
-					delete(chatImages, id)
-				}
+			if n := sweepImages(context.Background()); n > 0 {
+				log.Printf("🧹 Removed %d orphaned chat images\n", n)
 			}
-			imgMu.Unlock()
 		}
 	}()
 }
 
 /* ===================== MAIN ===================== */
 
+// openStore uses Postgres when POSTGRES_DSN is set, unless CHAT_STORE=memory
+// asks for the in-memory store (dev only, history is lost on restart).
+func openStore() MessageStore {
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" || os.Getenv("CHAT_STORE") == "memory" {
+		log.Println("⚠️ Chat history kept in memory")
+		return newMemStore()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, err := newPgStore(ctx, dsn)
+	if err != nil {
+		log.Fatal("chat store: ", err)
+	}
+
+	log.Println("✅ Chat history stored in PostgreSQL")
+	return s
+}
+
 func main() {
-	startCleanup()
+	if err := web.SetTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		log.Fatal("TRUSTED_PROXIES: ", err)
+	}
+
+	cors, err := web.CORSPolicyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	web.SetCORSPolicy(cors)
 
-	http.HandleFunc("/chat/send", withCORS(sendChat))
-	http.HandleFunc("/chat/image/send", withCORS(sendImage))
-	http.HandleFunc("/chat/image/", withCORS(serveImage))
-	http.HandleFunc("/chat/list", withCORS(listChat))
-	http.HandleFunc("/chat/room", withCORS(deleteRoom))
+	store = openStore()
+	blobs = openBlobStore()
+	startCleanup()
 
-	log.Println("💬 Chat server :8081 (TEXT + IMAGE + /doi CMD)")
+	// RATE_LIMIT_<NAME>_IP / _SESSION override these defaults
+	sendLimit := web.NewRateLimiter("chat_send",
+		web.Limit{Burst: 120, Per: time.Minute}, web.Limit{Burst: 30, Per: time.Minute}, memberRateKey)
+	imageLimit := web.NewRateLimiter("chat_image",
+		web.Limit{Burst: 30, Per: time.Minute}, web.Limit{Burst: 10, Per: time.Minute}, memberRateKey)
+
+	http.HandleFunc("/chat/send", web.WithCORS(sendLimit.Wrap(memberOnly(sendChat))))
+	http.HandleFunc("/chat/image/send", web.WithCORS(imageLimit.Wrap(memberOnly(sendImage))))
+	http.HandleFunc("/chat/image/upload", web.WithCORS(imageLimit.Wrap(memberOnly(uploadImage))))
+	http.HandleFunc("/chat/image/", web.WithCORS(memberOnly(serveImage)))
+	http.HandleFunc("/chat/list", web.WithCORS(memberOnly(listChat)))
+	http.HandleFunc("/chat/delete", web.WithCORS(memberOnly(deleteMessage)))
+	http.HandleFunc("/chat/room", web.WithCORS(adminOnly(deleteRoom)))
+
+	http.HandleFunc("/chat/admin/export", adminOnly(exportUser))
+	http.HandleFunc("/chat/admin/erase", adminOnly(eraseUser))
+	http.HandleFunc("/chat/admin/metrics/ratelimit", adminOnly(rateLimitMetrics))
+
+	log.Println("💬 Chat server :8081 (TEXT + IMAGE)")
 	log.Fatal(http.ListenAndServe(":8081", nil))
 }
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS chat_images;
+DROP TABLE IF EXISTS chat_messages;
@@ -0,0 +1,34 @@
+-- IF NOT EXISTS adopts the tables of deployments that predate versioned
+-- migrations, when the chat service created them on startup.
+CREATE TABLE IF NOT EXISTS chat_messages (
+	id BIGSERIAL PRIMARY KEY,
+	room TEXT NOT NULL,
+	username TEXT NOT NULL,
+	type TEXT NOT NULL,
+	text TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_room
+	ON chat_messages (room, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_user
+	ON chat_messages (username);
+
+CREATE TABLE IF NOT EXISTS chat_images (
+	id TEXT PRIMARY KEY,
+	message_id BIGINT NOT NULL,
+	room TEXT NOT NULL,
+	username TEXT NOT NULL,
+	mime TEXT NOT NULL,
+	thumb_mime TEXT NOT NULL,
+	width INT NOT NULL,
+	height INT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_images_message
+	ON chat_images (message_id);
+
+CREATE INDEX IF NOT EXISTS idx_chat_images_user
+	ON chat_images (username);
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+/* ===================== MESSAGE STORE ===================== */
+
+// MessageStore persists chat messages. List pages backwards from before
+// (an id, 0 meaning "latest") and returns the page oldest first.
+type MessageStore interface {
+	Append(ctx context.Context, msg ChatMessage) (ChatMessage, error)
+	List(ctx context.Context, room string, before int64, limit int) ([]ChatMessage, error)
+	DeleteRoom(ctx context.Context, room string) error
+	// DeleteMessage removes one message of room; errMessageNotFound if
+	// room has no message with that id.
+	DeleteMessage(ctx context.Context, room string, id int64) error
+
+	ListByUser(ctx context.Context, user string) ([]ChatMessage, error)
+	DeleteByUser(ctx context.Context, user string) (int, error)
+
+	ImageStore
+}
+
+var (
+	errImageNotFound   = errors.New("image not found")
+	errMessageNotFound = errors.New("message not found")
+)
+
+// ImageStore keeps image metadata next to the messages. An image lives as
+// long as the message that posted it: once the message is gone (room
+// closed, user erased, trimmed from memory) the image is an orphan and
+// the sweeper removes its blobs.
+type ImageStore interface {
+	AddImage(ctx context.Context, img ChatImage) error
+	GetImage(ctx context.Context, id string) (ChatImage, error)
+	ImagesByUser(ctx context.Context, user string) ([]ChatImage, error)
+	OrphanImages(ctx context.Context, limit int) ([]ChatImage, error)
+	DeleteImage(ctx context.Context, id string) error
+}
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func pageSize(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+	if limit > maxPageSize {
+		return maxPageSize
+	}
+	return limit
+}
+
+/* ===================== STORE (MEM) ===================== */
+
+// memRoomCap bounds how many messages a room keeps in memory.
+const memRoomCap = 500
+
+type memStore struct {
+	mu     sync.Mutex
+	nextID int64
+	rooms  map[string][]ChatMessage
+	images map[string]ChatImage
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		rooms:  make(map[string][]ChatMessage),
+		images: make(map[string]ChatImage),
+	}
+}
+
+func (s *memStore) Append(ctx context.Context, msg ChatMessage) (ChatMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	msg.ID = s.nextID
+	msg.Ts = time.Now().Unix()
+
+	list := append(s.rooms[msg.Room], msg)
+	if len(list) > memRoomCap {
+		list = list[len(list)-memRoomCap:]
+	}
+	s.rooms[msg.Room] = list
+
+	return msg, nil
+}
+
+func (s *memStore) List(ctx context.Context, room string, before int64, limit int) ([]ChatMessage, error) {
+	limit = pageSize(limit)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.rooms[room]
+	end := len(list)
+	if before > 0 {
+		end = 0
+		for end < len(list) && list[end].ID < before {
+			end++
+		}
+	}
+
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+
+	return append([]ChatMessage{}, list[start:end]...), nil
+}
+
+func (s *memStore) DeleteRoom(ctx context.Context, room string) error {
+	s.mu.Lock()
+	delete(s.rooms, room)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memStore) DeleteMessage(ctx context.Context, room string, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.rooms[room]
+	for i, m := range list {
+		if m.ID == id {
+			s.rooms[room] = append(list[:i], list[i+1:]...)
+			return nil
+		}
+	}
+	return errMessageNotFound
+}
+
+func (s *memStore) ListByUser(ctx context.Context, user string) ([]ChatMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := []ChatMessage{}
+	for _, list := range s.rooms {
+		for _, m := range list {
+			if m.User == user {
+				res = append(res, m)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (s *memStore) DeleteByUser(ctx context.Context, user string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for room, list := range s.rooms {
+		out := list[:0]
+		for _, m := range list {
+			if m.User == user {
+				n++
+				continue
+			}
+			out = append(out, m)
+		}
+		s.rooms[room] = out
+	}
+	return n, nil
+}
+
+func (s *memStore) AddImage(ctx context.Context, img ChatImage) error {
+	s.mu.Lock()
+	s.images[img.ID] = img
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memStore) GetImage(ctx context.Context, id string) (ChatImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	img, ok := s.images[id]
+	if !ok {
+		return ChatImage{}, errImageNotFound
+	}
+	return img, nil
+}
+
+func (s *memStore) ImagesByUser(ctx context.Context, user string) ([]ChatImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := []ChatImage{}
+	for _, img := range s.images {
+		if img.User == user {
+			res = append(res, img)
+		}
+	}
+	return res, nil
+}
+
+func (s *memStore) OrphanImages(ctx context.Context, limit int) ([]ChatImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	live := make(map[int64]bool)
+	for _, list := range s.rooms {
+		for _, m := range list {
+			live[m.ID] = true
+		}
+	}
+
+	res := []ChatImage{}
+	for _, img := range s.images {
+		if len(res) >= limit {
+			break
+		}
+		if !live[img.MessageID] {
+			res = append(res, img)
+		}
+	}
+	return res, nil
+}
+
+func (s *memStore) DeleteImage(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.images, id)
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"time"
+
+	"my-source/loto-full/shared/migrate"
+
+	_ "github.com/lib/pq"
+)
+
+/* ===================== STORE (POSTGRES) ===================== */
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// migrations are versioned apart from the loto API's, which share the
+// database.
+var migrations = migrate.Set{
+	FS:     migrationFS,
+	Dir:    "migrations",
+	Table:  "chat_schema_migrations",
+	LockID: 7243002,
+}
+
+type pgStore struct {
+	db *sql.DB
+}
+
+func newPgStore(ctx context.Context, dsn string) (*pgStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrations.Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &pgStore{db: db}, nil
+}
+
+func (s *pgStore) Append(ctx context.Context, msg ChatMessage) (ChatMessage, error) {
+	var created time.Time
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO chat_messages (room, username, type, text)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, msg.Room, msg.User, msg.Type, msg.Text).Scan(&msg.ID, &created)
+	if err != nil {
+		return msg, err
+	}
+
+	msg.Ts = created.Unix()
+	return msg, nil
+}
+
+func (s *pgStore) List(ctx context.Context, room string, before int64, limit int) ([]ChatMessage, error) {
+	limit = pageSize(limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, room, username, type, text, created_at
+		FROM chat_messages
+		WHERE room = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, room, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list, nil
+}
+
+func (s *pgStore) DeleteRoom(ctx context.Context, room string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM chat_messages WHERE room = $1
+	`, room)
+	return err
+}
+
+func (s *pgStore) DeleteMessage(ctx context.Context, room string, id int64) error {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM chat_messages WHERE room = $1 AND id = $2
+	`, room, id)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errMessageNotFound
+	}
+	return nil
+}
+
+func (s *pgStore) ListByUser(ctx context.Context, user string) ([]ChatMessage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, room, username, type, text, created_at
+		FROM chat_messages
+		WHERE username = $1
+		ORDER BY id
+	`, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func (s *pgStore) DeleteByUser(ctx context.Context, user string) (int, error) {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM chat_messages WHERE username = $1
+	`, user)
+	if err != nil {
+		return 0, err
+	}
+
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func scanMessages(rows *sql.Rows) ([]ChatMessage, error) {
+	res := []ChatMessage{}
+	for rows.Next() {
+		var m ChatMessage
+		var created time.Time
+		if err := rows.Scan(&m.ID, &m.Room, &m.User, &m.Type, &m.Text, &created); err != nil {
+			return nil, err
+		}
+		m.Ts = created.Unix()
+		res = append(res, m)
+	}
+
+	return res, rows.Err()
+}
+
+/* ===================== IMAGES (POSTGRES) ===================== */
+
+func (s *pgStore) AddImage(ctx context.Context, img ChatImage) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO chat_images (id, message_id, room, username, mime, thumb_mime, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, img.ID, img.MessageID, img.Room, img.User, img.Mime, img.ThumbMime, img.Width, img.Height)
+	return err
+}
+
+func (s *pgStore) GetImage(ctx context.Context, id string) (ChatImage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, message_id, room, username, mime, thumb_mime, width, height, created_at
+		FROM chat_images
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return ChatImage{}, err
+	}
+	defer rows.Close()
+
+	list, err := scanImages(rows)
+	if err != nil {
+		return ChatImage{}, err
+	}
+	if len(list) == 0 {
+		return ChatImage{}, errImageNotFound
+	}
+	return list[0], nil
+}
+
+func (s *pgStore) ImagesByUser(ctx context.Context, user string) ([]ChatImage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, message_id, room, username, mime, thumb_mime, width, height, created_at
+		FROM chat_images
+		WHERE username = $1
+		ORDER BY created_at
+	`, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanImages(rows)
+}
+
+func (s *pgStore) OrphanImages(ctx context.Context, limit int) ([]ChatImage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT i.id, i.message_id, i.room, i.username, i.mime, i.thumb_mime, i.width, i.height, i.created_at
+		FROM chat_images i
+		WHERE NOT EXISTS (SELECT 1 FROM chat_messages m WHERE m.id = i.message_id)
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanImages(rows)
+}
+
+func (s *pgStore) DeleteImage(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM chat_images WHERE id = $1
+	`, id)
+	return err
+}
+
+func scanImages(rows *sql.Rows) ([]ChatImage, error) {
+	res := []ChatImage{}
+	for rows.Next() {
+		var img ChatImage
+		var created time.Time
+		if err := rows.Scan(&img.ID, &img.MessageID, &img.Room, &img.User,
+			&img.Mime, &img.ThumbMime, &img.Width, &img.Height, &created); err != nil {
+			return nil, err
+		}
+		img.Time = created.Unix()
+		res = append(res, img)
+	}
+
+	return res, rows.Err()
+}
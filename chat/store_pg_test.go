@@ -0,0 +1,9 @@
+package main
+
+import "testing"
+
+func TestEmbeddedMigrations(t *testing.T) {
+	if err := migrations.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
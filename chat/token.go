@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"my-source/loto-full/shared/token"
+)
+
+/* ===================== MEMBERSHIP TOKENS ===================== */
+
+// chatTokenSecret is shared with the loto API, which signs a membership
+// token on join; verifying it here needs no call back to the API.
+var chatTokenSecret = os.Getenv("CHAT_TOKEN_SECRET")
+
+// verifyMemberToken checks a membership token and its expiry.
+func verifyMemberToken(tok string) (token.ChatClaims, error) {
+	return token.VerifyChat([]byte(chatTokenSecret), tok)
+}
+
+type memberKey struct{}
+
+// memberOnly requires a valid membership token, sent as a Bearer header
+// or, for <img> tags that cannot set headers, a "token" query parameter.
+// Handlers take room and user from memberFrom, never from the request.
+func memberOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if chatTokenSecret == "" {
+			http.Error(w, "chat token secret not configured", http.StatusInternalServerError)
+			return
+		}
+
+		claims, err := verifyMemberToken(requestToken(r))
+		if err != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		h(w, r.WithContext(context.WithValue(r.Context(), memberKey{}, claims)))
+	}
+}
+
+// requestToken returns the Bearer token, falling back to ?token=.
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return r.URL.Query().Get("token")
+}
+
+// memberRateKey keys the per-session rate limit by room and user rather
+// than by token, since ping hands out a fresh token every few seconds.
+func memberRateKey(r *http.Request) string {
+	c, err := verifyMemberToken(requestToken(r))
+	if err != nil {
+		return ""
+	}
+	return c.Room + "\x00" + c.User
+}
+
+func memberFrom(r *http.Request) token.ChatClaims {
+	c, _ := r.Context().Value(memberKey{}).(token.ChatClaims)
+	return c
+}
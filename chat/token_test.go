@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"my-source/loto-full/shared/token"
+)
+
+func memberToken(t *testing.T, c token.ChatClaims) string {
+	t.Helper()
+	tok, err := token.Sign([]byte(chatTokenSecret), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func TestMemberRateKey(t *testing.T) {
+	old := chatTokenSecret
+	chatTokenSecret = "chat-key"
+	t.Cleanup(func() { chatTokenSecret = old })
+
+	key := func(tok string) string {
+		r := httptest.NewRequest("POST", "/chat/send", nil)
+		r.Header.Set("Authorization", "Bearer "+tok)
+		return memberRateKey(r)
+	}
+	exp := time.Now().Add(time.Minute).Unix()
+
+	// ping hands out a fresh token every few seconds; the bucket stays
+	first := key(memberToken(t, token.ChatClaims{Room: "r1", User: "ann", Exp: exp}))
+	rotated := key(memberToken(t, token.ChatClaims{Room: "r1", User: "ann", Exp: exp + 5, Mod: true}))
+	if first == "" || first != rotated {
+		t.Errorf("rate keys %q and %q, want one non-empty key", first, rotated)
+	}
+	if other := key(memberToken(t, token.ChatClaims{Room: "r1", User: "bob", Exp: exp})); other == first {
+		t.Error("two members share a rate key")
+	}
+	if got := key("garbage"); got != "" {
+		t.Errorf("rate key of an invalid token = %q", got)
+	}
+}
+
+func TestMemberOnly(t *testing.T) {
+	old := chatTokenSecret
+	chatTokenSecret = "chat-key"
+	t.Cleanup(func() { chatTokenSecret = old })
+
+	var got token.ChatClaims
+	h := memberOnly(func(w http.ResponseWriter, r *http.Request) { got = memberFrom(r) })
+	serve := func(target string) int {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", target, nil))
+		return w.Code
+	}
+
+	valid := memberToken(t, token.ChatClaims{Room: "r1", User: "ann", Exp: time.Now().Add(time.Minute).Unix()})
+	expired := memberToken(t, token.ChatClaims{Room: "r1", User: "ann", Exp: time.Now().Unix()})
+
+	if code := serve("/chat/image?token=" + valid); code != http.StatusOK || got.Room != "r1" || got.User != "ann" {
+		t.Errorf("valid token: %d, claims %+v", code, got)
+	}
+	if code := serve("/chat/image?token=" + expired); code != http.StatusUnauthorized {
+		t.Errorf("expired token: %d, want 401", code)
+	}
+	if code := serve("/chat/image"); code != http.StatusUnauthorized {
+		t.Errorf("no token: %d, want 401", code)
+	}
+}
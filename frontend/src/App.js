@@ -28,7 +28,13 @@ export default function App() {
 
   const [room, setRoom] = useState(
     parsed?.roomId
-      ? { id: parsed.roomId, secret: parsed.secret }
+      ? {
+          id: parsed.roomId,
+          token: parsed.token,
+          adminToken: parsed.adminToken,
+          moderatorToken: parsed.moderatorToken,
+          chatToken: parsed.chatToken,
+        }
       : null
   );
 
@@ -46,7 +52,10 @@ export default function App() {
         user,
         displayName,
         roomId: room.id,
-        secret: room.secret,
+        token: room.token,
+        adminToken: room.adminToken,
+        moderatorToken: room.moderatorToken,
+        chatToken: room.chatToken,
       })
     );
   }, [user, displayName, room]);
@@ -65,7 +74,14 @@ export default function App() {
       user={user}
       displayName={displayName}
       roomId={room.id}
-      secret={room.secret}
+      token={room.token}
+      adminToken={room.adminToken}
+      moderatorToken={room.moderatorToken}
+      chatToken={room.chatToken}
+      onAdminToken={(adminToken) => setRoom((r) => ({ ...r, adminToken }))}
+      onModeratorToken={(moderatorToken) =>
+        setRoom((r) => ({ ...r, moderatorToken }))
+      }
       onLeave={leaveRoom}
     />
   ) : (
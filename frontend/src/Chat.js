@@ -14,11 +14,20 @@ import ImageIcon from "@mui/icons-material/Image";
 
 const CHAT_API = process.env.REACT_APP_CHAT_API || "http://localhost:8081";
 
-// 🔧 helper: detect command
-const isCommand = (text) =>
-  typeof text === "string" && text.includes("/doi");
+// the loto API marks owner and moderator tokens with "mod"
+const canModerate = (tok) => {
+  try {
+    const b64 = tok.split(".")[0].replace(/-/g, "+").replace(/_/g, "/");
+    return !!JSON.parse(atob(b64)).mod;
+  } catch {
+    return false;
+  }
+};
+
+export default function Chat({ roomId, user, token }) {
+  const auth = { Authorization: `Bearer ${token}` };
+  const isMod = canModerate(token);
 
-export default function Chat({ roomId, user }) {
   const [open, setOpen] = useState(false);
   const [text, setText] = useState("");
   const [chats, setChats] = useState([]);
@@ -39,24 +48,25 @@ export default function Chat({ roomId, user }) {
 
   const loadChat = async () => {
     try {
-      const res = await fetch(`${CHAT_API}/chat/list?room=${roomId}`);
+      const res = await fetch(`${CHAT_API}/chat/list?room=${roomId}`, {
+        headers: auth,
+      });
+      if (!res.ok) return;
       const data = (await res.json()) || [];
 
-      const visible = data.filter((c) => !isCommand(c.text));
-
       if (lastCountRef.current === null) {
-        lastCountRef.current = visible.length;
+        lastCountRef.current = data.length;
         setChats(data);
         return;
       }
 
       if (!open) {
-        const diff = visible.length - lastCountRef.current;
+        const diff = data.length - lastCountRef.current;
         setUnread(diff > 0 ? diff : 0);
       }
 
       if (open) {
-        lastCountRef.current = visible.length;
+        lastCountRef.current = data.length;
         setUnread(0);
       }
 
@@ -66,6 +76,19 @@ export default function Chat({ roomId, user }) {
     }
   };
 
+  const deleteMessage = async (id) => {
+    if (!window.confirm("Delete this message?")) return;
+    try {
+      await fetch(`${CHAT_API}/chat/delete?id=${id}`, {
+        method: "POST",
+        headers: auth,
+      });
+      loadChat();
+    } catch (e) {
+      console.error("Delete chat error", e);
+    }
+  };
+
   /* ================= EFFECTS ================= */
 
   useEffect(() => {
@@ -92,12 +115,8 @@ export default function Chat({ roomId, user }) {
     try {
       await fetch(`${CHAT_API}/chat/send`, {
         method: "POST",
-        headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({
-          room: roomId,
-          user,
-          text: msg,
-        }),
+        headers: { "Content-Type": "application/json", ...auth },
+        body: JSON.stringify({ text: msg }),
       });
 
       // ✅ push vào history
@@ -116,26 +135,26 @@ export default function Chat({ roomId, user }) {
   /* ================= SEND IMAGE ================= */
 
   const sendImage = async (file) => {
-    if (!file || !file.type.startsWith("image/") || sending) return;
+    if (!file || sending) return;
+
+    const form = new FormData();
+    form.append("file", file);
 
     setSending(true);
     try {
-      const reader = new FileReader();
-      reader.onload = async () => {
-        await fetch(`${CHAT_API}/chat/image/send`, {
-          method: "POST",
-          headers: { "Content-Type": "application/json" },
-          body: JSON.stringify({
-            room: roomId,
-            user,
-            base64: reader.result,
-          }),
-        });
-
-        fileRef.current.value = "";
-        loadChat();
-      };
-      reader.readAsDataURL(file);
+      const res = await fetch(`${CHAT_API}/chat/image/upload`, {
+        method: "POST",
+        headers: auth,
+        body: form,
+      });
+      if (!res.ok) {
+        alert(await res.text());
+      }
+
+      fileRef.current.value = "";
+      await loadChat();
+    } catch (e) {
+      console.error("Send image failed", e);
     } finally {
       setSending(false);
     }
@@ -231,7 +250,6 @@ export default function Chat({ roomId, user }) {
 
           <Box sx={{ flex: 1, overflowY: "auto" }}>
             {chats.map((c, i) => {
-              if (isCommand(c.text)) return null;
               const isMe = c.user === user;
 
               return (
@@ -254,18 +272,34 @@ export default function Chat({ roomId, user }) {
                   >
                     <Typography fontSize={11} fontWeight="bold">
                       {c.user}
+                      {isMod && (
+                        <span
+                          onClick={() => deleteMessage(c.id)}
+                          style={{ marginLeft: 6, cursor: "pointer" }}
+                          title="Delete message"
+                        >
+                          🗑️
+                        </span>
+                      )}
                     </Typography>
 
                     {c.type === "image" ? (
-                      <img
-                        src={`${CHAT_API}${c.text}`}
-                        style={{
-                          maxWidth: "100%",
-                          borderRadius: 6,
-                          marginTop: 4,
-                        }}
-                        alt="chat-img"
-                      />
+                      <a
+                        href={`${CHAT_API}${c.text}?token=${token}`}
+                        target="_blank"
+                        rel="noreferrer"
+                      >
+                        <img
+                          src={`${CHAT_API}${c.text}?thumb=1&token=${token}`}
+                          loading="lazy"
+                          style={{
+                            maxWidth: "100%",
+                            borderRadius: 6,
+                            marginTop: 4,
+                          }}
+                          alt="chat-img"
+                        />
+                      </a>
                     ) : (
                       <Typography fontSize={13}>{c.text}</Typography>
                     )}
@@ -287,7 +321,7 @@ export default function Chat({ roomId, user }) {
             <input
               ref={fileRef}
               type="file"
-              accept="image/*"
+              accept="image/png,image/jpeg,image/gif,image/webp"
               hidden
               onChange={(e) => sendImage(e.target.files[0])}
             />
@@ -1,6 +1,8 @@
 import { useEffect, useRef, useState } from "react";
+import { THEMES } from "./components/RoomInfoDialog";
 
 const API = process.env.REACT_APP_LOTO_API || "http://localhost:8080";
+const PAGE_SIZE = 10;
 
 /* ================= RANDOM USER SUFFIX ================= */
 const randomSuffix = () => {
@@ -20,8 +22,20 @@ export default function Lobby({
   setUser,
 }) {
   const [rooms, setRooms] = useState([]);
+  const [total, setTotal] = useState(0);
+  const [search, setSearch] = useState("");
+  const [themeFilter, setThemeFilter] = useState("");
+  const [offset, setOffset] = useState(0);
   const [roomId, setRoomId] = useState("");
   const [secret, setSecret] = useState("");
+  const [visibility, setVisibility] = useState("unlisted");
+  const [title, setTitle] = useState("");
+  const [theme, setTheme] = useState("");
+  const [tags, setTags] = useState("");
+  // an invite link opens the lobby with ?invite=<token>
+  const [invite, setInvite] = useState(
+    () => new URLSearchParams(window.location.search).get("invite") || ""
+  );
 
   /* ===== random prefix chỉ tạo 1 lần ===== */
   const randomRef = useRef(user?.split("-")[0] || randomSuffix());
@@ -41,9 +55,16 @@ export default function Lobby({
   /* ================= LOAD ROOMS ================= */
   const loadRooms = async () => {
     try {
-      const res = await fetch(`${API}/rooms`);
+      const q = new URLSearchParams({
+        q: search,
+        theme: themeFilter,
+        limit: PAGE_SIZE,
+        offset,
+      });
+      const res = await fetch(`${API}/rooms?${q}`);
       const data = await res.json();
-      setRooms(data || []);
+      setRooms(data.rooms || []);
+      setTotal(data.total || 0);
     } catch (e) {
       console.error("loadRooms error:", e);
     }
@@ -53,38 +74,79 @@ export default function Lobby({
     loadRooms();
     const t = setInterval(loadRooms, 2000);
     return () => clearInterval(t);
-  }, []);
+  }, [search, themeFilter, offset]);
 
   /* ================= CREATE ROOM ================= */
   const createRoom = async () => {
-    if (!roomId.trim() || !secret.trim())
-      return alert("Nhập Room ID và Secret");
+    if (!roomId.trim()) return alert("Nhập Room ID");
+    if (visibility === "unlisted" && !secret.trim())
+      return alert("Nhập Secret");
     if (!displayName.trim())
       return alert("Tên hiển thị không hợp lệ");
 
     const res = await fetch(
-      `${API}/rooms/create?id=${roomId}&user=${user}&secret=${secret}`,
+      `${API}/rooms/create?id=${roomId}&user=${user}&secret=${encodeURIComponent(
+        secret
+      )}&visibility=${visibility}&title=${encodeURIComponent(
+        title
+      )}&theme=${theme}&tags=${encodeURIComponent(tags)}`,
       { method: "POST" }
     );
 
-    if (!res.ok) return alert("Room already exists");
+    if (!res.ok) return alert(await res.text());
 
-    onJoin({ id: roomId, secret, user });
+    const { token, adminToken, chatToken } = await res.json();
+    onJoin({ id: roomId, user, token, adminToken, chatToken });
   };
 
   /* ================= JOIN ROOM ================= */
+  // listed rooms are public and need no secret
   const joinRoom = async (id) => {
-    const s = prompt("Enter room secret:");
-    if (!s) return;
+    const res = await fetch(`${API}/rooms/join?id=${id}&user=${user}`, {
+      method: "POST",
+    });
+
+    if (!res.ok) return alert("❌ Cannot join room");
+
+    const { token, chatToken } = await res.json();
+    onJoin({ id, user, token, chatToken });
+  };
+
+  const joinWithSecret = async () => {
+    if (!roomId.trim() || !secret.trim())
+      return alert("Nhập Room ID và Secret");
 
     const res = await fetch(
-      `${API}/rooms/join?id=${id}&user=${user}&secret=${s}`,
+      `${API}/rooms/join?id=${roomId}&user=${user}&secret=${encodeURIComponent(secret)}`,
       { method: "POST" }
     );
 
     if (!res.ok) return alert("❌ Wrong secret");
 
-    onJoin({ id, secret: s, user });
+    const { token, chatToken } = await res.json();
+    onJoin({ id: roomId, user, token, chatToken });
+  };
+
+  const joinWithInvite = async () => {
+    if (!invite.trim()) return alert("Nhập mã mời");
+    if (!displayName.trim()) return alert("Tên hiển thị không hợp lệ");
+
+    // a pasted link works as well as the bare token or code
+    let code = invite.trim();
+    try {
+      code = new URL(code).searchParams.get("invite") || code;
+    } catch {}
+
+    const res = await fetch(
+      `${API}/rooms/join?user=${user}&invite=${encodeURIComponent(code)}`,
+      { method: "POST" }
+    );
+
+    if (!res.ok) return alert("❌ Invite expired or invalid");
+
+    const { id, token, chatToken } = await res.json();
+    window.history.replaceState(null, "", window.location.pathname);
+    onJoin({ id, user, token, chatToken });
   };
 
   /* ================= UI STYLES ================= */
@@ -156,7 +218,7 @@ export default function Lobby({
             marginBottom: 20,
           }}
         >
-          <h4>Create Room</h4>
+          <h4>Create or Join Room</h4>
 
           <input
             placeholder="Room ID"
@@ -172,57 +234,180 @@ export default function Lobby({
             style={inputStyle}
           />
 
+          <select
+            value={visibility}
+            onChange={(e) => setVisibility(e.target.value)}
+            style={inputStyle}
+          >
+            <option value="public">Public – listed, no secret</option>
+            <option value="unlisted">Unlisted – secret or invite</option>
+            <option value="private">Private – invite only</option>
+          </select>
+
+          <input
+            placeholder="Title (optional)"
+            value={title}
+            onChange={(e) => setTitle(e.target.value)}
+            style={inputStyle}
+          />
+
+          <select
+            value={theme}
+            onChange={(e) => setTheme(e.target.value)}
+            style={inputStyle}
+          >
+            {THEMES.map((t) => (
+              <option key={t.value} value={t.value}>
+                {t.label}
+              </option>
+            ))}
+          </select>
+
+          <input
+            placeholder="Tags, comma separated (optional)"
+            value={tags}
+            onChange={(e) => setTags(e.target.value)}
+            style={inputStyle}
+          />
+
           <button onClick={createRoom} style={buttonStyle}>
             🧧 Create Room
           </button>
+
+          <button
+            onClick={joinWithSecret}
+            style={{ ...buttonStyle, marginTop: 8, background: "#2196f3" }}
+          >
+            🔑 Join with Secret
+          </button>
+        </div>
+
+        {/* JOIN BY INVITE */}
+        <div
+          style={{
+            border: "1px solid #ddd",
+            borderRadius: 8,
+            padding: 16,
+            marginBottom: 20,
+          }}
+        >
+          <h4>Join with Invite</h4>
+
+          <input
+            placeholder="Invite code or link"
+            value={invite}
+            onChange={(e) => setInvite(e.target.value)}
+            style={inputStyle}
+          />
+
+          <button onClick={joinWithInvite} style={buttonStyle}>
+            🎟️ Join
+          </button>
         </div>
 
         {/* ROOM LIST */}
         <div>
           <h4>Available Rooms</h4>
 
+          <div style={{ display: "flex", gap: 8 }}>
+            <input
+              placeholder="🔍 Search rooms"
+              value={search}
+              onChange={(e) => {
+                setSearch(e.target.value);
+                setOffset(0);
+              }}
+              style={{ ...inputStyle, flex: 2 }}
+            />
+            <select
+              value={themeFilter}
+              onChange={(e) => {
+                setThemeFilter(e.target.value);
+                setOffset(0);
+              }}
+              style={{ ...inputStyle, flex: 1 }}
+            >
+              <option value="">All themes</option>
+              {THEMES.filter((t) => t.value).map((t) => (
+                <option key={t.value} value={t.value}>
+                  {t.label}
+                </option>
+              ))}
+            </select>
+          </div>
+
           {rooms.length === 0 && (
             <p style={{ color: "#777" }}>No rooms available</p>
           )}
 
-          {[...rooms]
-            .sort((a, b) => a.id.localeCompare(b.id))
-            .map((r) => (
-              <div
-                key={r.id}
+          {/* the API already sorts by id */}
+          {rooms.map((r) => (
+            <div
+              key={r.id}
+              style={{
+                display: "flex",
+                justifyContent: "space-between",
+                alignItems: "center",
+                border: "1px solid #ddd",
+                borderRadius: 6,
+                padding: 10,
+                marginBottom: 8,
+                background: "#fff",
+              }}
+            >
+              <div>
+                <b>{r.title || r.id}</b>
+                {r.description && (
+                  <div style={{ fontSize: 12 }}>{r.description}</div>
+                )}
+                <div style={{ fontSize: 12, color: "#666" }}>
+                  👥 {r.players} | {r.running ? "Running" : "Waiting"}
+                  {r.language && ` | ${r.language}`}
+                  {r.tags?.map((t) => ` #${t}`)}
+                </div>
+              </div>
+
+              <button
+                onClick={() => joinRoom(r.id)}
                 style={{
-                  display: "flex",
-                  justifyContent: "space-between",
-                  alignItems: "center",
-                  border: "1px solid #ddd",
+                  padding: "8px 16px",
+                  background: "#2196f3",
+                  color: "#fff",
+                  border: "none",
                   borderRadius: 6,
-                  padding: 10,
-                  marginBottom: 8,
-                  background: "#fff",
+                  cursor: "pointer",
                 }}
               >
-                <div>
-                  <b>{r.id}</b>
-                  <div style={{ fontSize: 12, color: "#666" }}>
-                    👥 {r.players} | {r.running ? "Running" : "Waiting"}
-                  </div>
-                </div>
+                Join
+              </button>
+            </div>
+          ))}
 
-                <button
-                  onClick={() => joinRoom(r.id)}
-                  style={{
-                    padding: "8px 16px",
-                    background: "#2196f3",
-                    color: "#fff",
-                    border: "none",
-                    borderRadius: 6,
-                    cursor: "pointer",
-                  }}
-                >
-                  Join
-                </button>
-              </div>
-            ))}
+          {total > PAGE_SIZE && (
+            <div
+              style={{
+                display: "flex",
+                justifyContent: "space-between",
+                alignItems: "center",
+              }}
+            >
+              <button
+                disabled={offset === 0}
+                onClick={() => setOffset(Math.max(offset - PAGE_SIZE, 0))}
+              >
+                ◀ Prev
+              </button>
+              <span style={{ fontSize: 12, color: "#666" }}>
+                {offset + 1}–{Math.min(offset + PAGE_SIZE, total)} / {total}
+              </span>
+              <button
+                disabled={offset + PAGE_SIZE >= total}
+                onClick={() => setOffset(offset + PAGE_SIZE)}
+              >
+                Next ▶
+              </button>
+            </div>
+          )}
         </div>
       </div>
     </div>
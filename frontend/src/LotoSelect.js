@@ -46,7 +46,7 @@ const shortUser = (u) => {
     : u;
 };
 
-export default function LotoSelect({ roomId, user, state, API }) {
+export default function LotoSelect({ roomId, user, token, state, API }) {
   /* ================= STATE ================= */
   const [open, setOpen] = useState(false);
   const [currentLoto, setCurrentLoto] = useState(null);
@@ -158,17 +158,17 @@ export default function LotoSelect({ roomId, user, state, API }) {
 
   /* ================= API ================= */
   const selectLoto = async () => {
-    await fetch(
-      `${API}/rooms/loto/select?id=${roomId}&user=${user}&loto=${currentLoto}`,
-      { method: "POST" }
-    );
+    await fetch(`${API}/rooms/loto/select?id=${roomId}&loto=${currentLoto}`, {
+      method: "POST",
+      headers: { Authorization: `Bearer ${token}` },
+    });
   };
 
   const unselectLoto = async () => {
-    await fetch(
-      `${API}/rooms/loto/unselect?id=${roomId}&user=${user}&loto=${currentLoto}`,
-      { method: "POST" }
-    );
+    await fetch(`${API}/rooms/loto/unselect?id=${roomId}&loto=${currentLoto}`, {
+      method: "POST",
+      headers: { Authorization: `Bearer ${token}` },
+    });
   };
 
   const myLotos = Object.entries(state.lotos || {})
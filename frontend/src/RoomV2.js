@@ -7,15 +7,51 @@ import BingoQueue from "./components/BingoQueue";
 import BingoInput from "./components/BingoInput";
 import WinnerCard from "./components/WinnerCard";
 import UsersDialog from "./components/UserDialog";
+import InviteDialog from "./components/InviteDialog";
+import RoomInfoDialog from "./components/RoomInfoDialog";
 import Chat from "./Chat";
 import LotoSelect from "./LotoSelect";
 import CalledNumbers from "./Called";
 
 const API = process.env.REACT_APP_LOTO_API || "http://localhost:8080";
 
-export default function RoomV2({ roomId, user, secret, onLeave }) {
+// payload of a "payload.signature" token
+const tokenClaims = (tok) => {
+  try {
+    const b64 = tok.split(".")[0].replace(/-/g, "+").replace(/_/g, "/");
+    return JSON.parse(atob(b64));
+  } catch {
+    return {};
+  }
+};
+
+// room backgrounds by event theme; rooms without one keep the Tết look
+const BACKGROUNDS = {
+  tet: "url(/anhtet.jpg)",
+  mid_autumn: "linear-gradient(160deg, #1a237e 0%, #f57f17 100%)",
+  christmas: "linear-gradient(160deg, #1b5e20 0%, #b71c1c 100%)",
+};
+
+// seconds until a token expires
+const tokenTTL = (tok) => (tokenClaims(tok).exp || 0) - Date.now() / 1000;
+
+export default function RoomV2({
+  roomId,
+  user,
+  token,
+  adminToken,
+  moderatorToken,
+  chatToken: initialChatToken,
+  onAdminToken,
+  onModeratorToken,
+  onLeave,
+}) {
   const [state, setState] = useState(null);
+  // chat tokens are short-lived; ping hands out fresh ones
+  const [chatToken, setChatToken] = useState(initialChatToken);
   const [openUsers, setOpenUsers] = useState(false);
+  const [openInvite, setOpenInvite] = useState(false);
+  const [openInfo, setOpenInfo] = useState(false);
 
   const [bingoNums, setBingoNums] = useState("");
   const [bingoActive, setBingoActive] = useState(false);
@@ -29,7 +65,9 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
 
   const load = async () => {
     try {
-      const res = await fetch(`${API}/rooms/state?id=${roomId}`);
+      const res = await fetch(`${API}/rooms/state?id=${roomId}`, {
+        headers: { Authorization: `Bearer ${token}` },
+      });
 
       if (!res.ok) {
         onLeave();
@@ -60,24 +98,62 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
 
     const poll = setInterval(load, 1000);
 
-    const ping = setInterval(() => {
-      fetch(`${API}/rooms/ping?id=${roomId}&user=${user}`, {
-        method: "POST",
-      });
-    }, 5000);
+    const sendPing = async () => {
+      try {
+        // ping with our strongest token, chat moderation follows it
+        const res = await fetch(`${API}/rooms/ping?id=${roomId}`, {
+          method: "POST",
+          headers: {
+            Authorization: `Bearer ${adminToken || moderatorToken || token}`,
+          },
+        });
+
+        // kicked or banned
+        if (res.status === 403) {
+          alert("You were removed from this room");
+          onLeave();
+          return;
+        }
+        if (!res.ok) return;
+
+        const data = await res.json();
+        // the room was handed to us
+        if (data.adminToken) onAdminToken(data.adminToken);
+        // or we were made a moderator
+        if (data.moderatorToken) onModeratorToken(data.moderatorToken);
+        // swap only near expiry, or when our role changed, so image URLs
+        // stay cacheable
+        setChatToken((cur) =>
+          data.chatToken &&
+          (tokenTTL(cur) < 120 ||
+            !!tokenClaims(cur).mod !== !!tokenClaims(data.chatToken).mod)
+            ? data.chatToken
+            : cur
+        );
+      } catch (e) {
+        console.error("ping error:", e);
+      }
+    };
+
+    sendPing();
+    const ping = setInterval(sendPing, 5000);
 
     return () => {
       mountedRef.current = false;
       clearInterval(poll);
       clearInterval(ping);
     };
-  }, [roomId, user]);
+  }, [roomId, user, token, adminToken, moderatorToken]);
 
   if (!state) {
     return <p style={{ padding: 20 }}>Loading room...</p>;
   }
 
   const isAdmin = state.admin === user;
+  const role = isAdmin ? "owner" : state.roles?.[user] || "player";
+  // moderators review claims and hold the draw with their moderator token
+  const isMod = isAdmin || role === "moderator";
+  const modToken = isAdmin ? adminToken : moderatorToken;
 
   const canStartGame = isAdmin && !state.running && !state.winner;
   const canResetGame = isAdmin && !!state.winner;
@@ -88,7 +164,7 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
     <Box
       sx={{
         minHeight: "100vh",
-        backgroundImage: "url(/anhtet.jpg)",
+        backgroundImage: BACKGROUNDS[state.meta?.theme] || BACKGROUNDS.tet,
         backgroundSize: "cover",
         backgroundPosition: "center",
         backgroundRepeat: "no-repeat",
@@ -101,10 +177,17 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
             <RoomHeader
               roomId={roomId}
               user={user}
+              token={token}
+              adminToken={adminToken}
               state={state}
               isAdmin={isAdmin}
+              isMod={isMod}
+              modToken={modToken}
+              role={role}
               onLeave={onLeave}
               onShowUsers={() => setOpenUsers(true)}
+              onShowInvite={() => setOpenInvite(true)}
+              onShowInfo={() => setOpenInfo(true)}
               API={API}
               voiceOn={voiceOn}
               setVoiceOn={setVoiceOn}
@@ -119,10 +202,10 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
               onStart={
                 canStartGame
                   ? () =>
-                      fetch(
-                        `${API}/rooms/start?id=${roomId}&secret=${secret}`,
-                        { method: "POST" }
-                      )
+                      fetch(`${API}/rooms/start?id=${roomId}`, {
+                        method: "POST",
+                        headers: { Authorization: `Bearer ${adminToken}` },
+                      })
                   : undefined
               }
               voiceOn={voiceOn}
@@ -130,26 +213,31 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
 
             <BingoQueue
               state={state}
-              isAdmin={isAdmin}
+              isAdmin={isMod}
+              adminToken={modToken}
               API={API}
               roomId={roomId}
             />
 
-            <BingoInput
-              state={state}
-              user={user}
-              roomId={roomId}
-              API={API}
-              bingoNums={bingoNums}
-              setBingoNums={setBingoNums}
-              bingoActive={bingoActive}
-              setBingoActive={setBingoActive}
-              voiceOn={voiceOn}
-            />
+            {role !== "spectator" && (
+              <BingoInput
+                state={state}
+                user={user}
+                token={token}
+                roomId={roomId}
+                API={API}
+                bingoNums={bingoNums}
+                setBingoNums={setBingoNums}
+                bingoActive={bingoActive}
+                setBingoActive={setBingoActive}
+                voiceOn={voiceOn}
+              />
+            )}
 
             <WinnerCard
               winner={state.winner}
               nums={state.winnerNums}
+              forced={state.forcedNumbers}
               voiceOn={voiceOn}
             />
 
@@ -159,6 +247,7 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
                   onClick={async () => {
                     await fetch(`${API}/rooms/restart?id=${roomId}`, {
                       method: "POST",
+                      headers: { Authorization: `Bearer ${adminToken}` },
                     });
                     setBingoNums("");
                     setBingoActive(false);
@@ -178,12 +267,15 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
               </Box>
             )}
 
-            <LotoSelect
-              roomId={roomId}
-              user={user}
-              state={state}
-              API={API}
-            />
+            {role !== "spectator" && (
+              <LotoSelect
+                roomId={roomId}
+                user={user}
+                token={token}
+                state={state}
+                API={API}
+              />
+            )}
 
             <CalledNumbers called={state.called || []} />
           </CardContent>
@@ -194,10 +286,31 @@ export default function RoomV2({ roomId, user, secret, onLeave }) {
           onClose={() => setOpenUsers(false)}
           users={state.users}
           admin={state.admin}
+          coHost={state.coHost}
+          roles={state.roles}
           me={user}
+          adminToken={isAdmin ? adminToken : null}
         />
 
-        <Chat roomId={roomId} user={user} />
+        {isAdmin && (
+          <InviteDialog
+            open={openInvite}
+            onClose={() => setOpenInvite(false)}
+            visibility={state.visibility}
+            adminToken={adminToken}
+          />
+        )}
+
+        {isAdmin && (
+          <RoomInfoDialog
+            open={openInfo}
+            onClose={() => setOpenInfo(false)}
+            meta={state.meta}
+            adminToken={adminToken}
+          />
+        )}
+
+        <Chat roomId={roomId} user={user} token={chatToken} />
       </Box>
     </Box>
   );
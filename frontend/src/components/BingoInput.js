@@ -4,6 +4,7 @@ import { speak } from "../utils/utils";
 export default function BingoInput({
   state,
   user,
+  token,
   roomId,
   API,
   bingoNums,
@@ -24,8 +25,9 @@ export default function BingoInput({
   const startBingo = async () => {
     if (voiceOn) speak("Kinh Kinh Kinh!!");
 
-    await fetch(`${API}/rooms/bingo?id=${roomId}&user=${user}&nums=`, {
+    await fetch(`${API}/rooms/bingo?id=${roomId}&nums=`, {
       method: "POST",
+      headers: { Authorization: `Bearer ${token}` },
     });
 
     setBingoActive(true);
@@ -42,10 +44,10 @@ export default function BingoInput({
       return;
     }
 
-    await fetch(
-      `${API}/rooms/bingo?id=${roomId}&user=${user}&nums=${nums.join(",")}`,
-      { method: "POST" }
-    );
+    await fetch(`${API}/rooms/bingo?id=${roomId}&nums=${nums.join(",")}`, {
+      method: "POST",
+      headers: { Authorization: `Bearer ${token}` },
+    });
 
     setBingoNums("");
     setBingoActive(false);
@@ -7,13 +7,14 @@ import {
   Box,
 } from "@mui/material";
 
-export default function BingoQueue({ state, isAdmin, API, roomId }) {
+export default function BingoQueue({ state, isAdmin, adminToken, API, roomId }) {
   const queue = state?.bingoQueue || [];
   if (!queue.length) return null;
 
   const approve = async () => {
     await fetch(`${API}/rooms/bingo/result?id=${roomId}&ok=1`, {
       method: "POST",
+      headers: { Authorization: `Bearer ${adminToken}` },
     });
     // ⛔ KHÔNG setState – polling sẽ cập nhật
   };
@@ -21,6 +22,7 @@ export default function BingoQueue({ state, isAdmin, API, roomId }) {
   const reject = async () => {
     await fetch(`${API}/rooms/bingo/result?id=${roomId}&ok=0`, {
       method: "POST",
+      headers: { Authorization: `Bearer ${adminToken}` },
     });
   };
 
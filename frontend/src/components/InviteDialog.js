@@ -0,0 +1,96 @@
+import { useState } from "react";
+import {
+  Dialog,
+  DialogTitle,
+  DialogContent,
+  Typography,
+  Stack,
+  Button,
+  Divider,
+  TextField,
+  MenuItem,
+} from "@mui/material";
+
+const API = process.env.REACT_APP_LOTO_API || "http://localhost:8080";
+
+export default function InviteDialog({ open, onClose, visibility, adminToken }) {
+  const [invite, setInvite] = useState(null);
+
+  const call = (path) =>
+    fetch(`${API}${path}`, {
+      method: "POST",
+      headers: { Authorization: `Bearer ${adminToken}` },
+    });
+
+  const createInvite = async () => {
+    const res = await call("/rooms/invite");
+    if (!res.ok) return alert("Cannot create invite");
+    setInvite(await res.json());
+  };
+
+  const revoke = async () => {
+    if (!window.confirm("Revoke every invite link and code?")) return;
+    await call("/rooms/invite/revoke");
+    setInvite(null);
+  };
+
+  const changeVisibility = async (v) => {
+    const res = await call(`/rooms/visibility?v=${v}`);
+    if (!res.ok) alert(await res.text());
+  };
+
+  return (
+    <Dialog open={open} onClose={onClose}>
+      <DialogTitle>🎟️ Invite</DialogTitle>
+      <DialogContent>
+        <TextField
+          select
+          fullWidth
+          size="small"
+          label="Visibility"
+          value={visibility || "unlisted"}
+          onChange={(e) => changeVisibility(e.target.value)}
+          sx={{ mt: 1 }}
+        >
+          <MenuItem value="public">Public – listed, no secret</MenuItem>
+          <MenuItem value="unlisted">Unlisted – secret or invite</MenuItem>
+          <MenuItem value="private">Private – invite only</MenuItem>
+        </TextField>
+
+        <Divider sx={{ my: 2 }} />
+
+        {invite && (
+          <Stack spacing={1} alignItems="center" mb={2}>
+            <Typography variant="h5" fontWeight="bold" letterSpacing={3}>
+              {invite.code}
+            </Typography>
+            <img
+              src={`${API}${invite.qr}`}
+              width={200}
+              height={200}
+              alt="invite QR code"
+            />
+            <Button
+              size="small"
+              onClick={() => navigator.clipboard?.writeText(invite.url)}
+            >
+              📋 Copy link
+            </Button>
+            <Typography variant="body2" color="text.secondary">
+              Expires {new Date(invite.expiresAt).toLocaleString()}
+            </Typography>
+          </Stack>
+        )}
+
+        <Stack direction="row" spacing={1}>
+          <Button variant="contained" onClick={createInvite}>
+            New invite
+          </Button>
+          <Button color="error" onClick={revoke}>
+            Revoke all
+          </Button>
+        </Stack>
+      </DialogContent>
+    </Dialog>
+  );
+}
@@ -15,10 +15,17 @@ import { useEffect, useState } from "react";
 export default function RoomHeader({
   roomId,
   user,
+  token,
+  adminToken,
   state,
   isAdmin,
+  isMod,
+  modToken,
+  role,
   onLeave,
   onShowUsers,
+  onShowInvite,
+  onShowInfo,
   API,
   voiceOn,
   setVoiceOn,
@@ -29,8 +36,9 @@ export default function RoomHeader({
   /* ================= ACTIONS ================= */
 
   const leaveRoom = async () => {
-    await fetch(`${API}/rooms/leave?id=${roomId}&user=${user}`, {
+    await fetch(`${API}/rooms/leave?id=${roomId}`, {
       method: "POST",
+      headers: { Authorization: `Bearer ${token}` },
     });
     onLeave();
   };
@@ -38,10 +46,20 @@ export default function RoomHeader({
   const setIntervalValue = async (v) => {
     await fetch(`${API}/rooms/interval?id=${roomId}&v=${v}`, {
       method: "POST",
+      headers: { Authorization: `Bearer ${adminToken}` },
     });
     setAnchorEl(null);
   };
 
+  const toggleHold = async () => {
+    await fetch(`${API}/rooms/hold?id=${roomId}&held=${state.held ? 0 : 1}`, {
+      method: "POST",
+      headers: { Authorization: `Bearer ${modToken}` },
+    });
+  };
+
+  const roleIcon = { owner: " 👑", moderator: " 🛡️", spectator: " 👀" }[role] || "";
+
   /* ================= RENDER ================= */
 
   return (
@@ -55,11 +73,13 @@ export default function RoomHeader({
     >
       {/* LEFT */}
       <Stack direction="row" spacing={1} alignItems="center">
-        <Chip
-          label={`🎱 ROOM: ${roomId}`}
-          color="primary"
-          sx={{ fontWeight: "bold" }}
-        />
+        <Tooltip title={state.meta?.description || ""}>
+          <Chip
+            label={`🎱 ${state.meta?.title || `ROOM: ${roomId}`}`}
+            color="primary"
+            sx={{ fontWeight: "bold" }}
+          />
+        </Tooltip>
 
         <Chip
           label={`👥 ${playerCount}`}
@@ -84,14 +104,29 @@ export default function RoomHeader({
 
         {/* USER */}
         <Chip
-          label={isAdmin ? `${user} 👑` : user}
+          label={`${user}${roleIcon}`}
           color={isAdmin ? "success" : "default"}
           sx={{ fontWeight: "bold" }}
         />
 
-        {/* INTERVAL (ADMIN ONLY) */}
+        {/* HOLD (OWNER & MODERATORS) */}
+        {isMod && state.running && (
+          <Button size="small" variant="outlined" onClick={toggleHold}>
+            {state.held ? "▶ Resume" : "⏸ Hold"}
+          </Button>
+        )}
+
+        {/* INFO, INVITE & INTERVAL (ADMIN ONLY) */}
         {isAdmin && (
           <>
+            <Button size="small" variant="outlined" onClick={onShowInfo}>
+              📝 Info
+            </Button>
+
+            <Button size="small" variant="outlined" onClick={onShowInvite}>
+              🎟️ Invite
+            </Button>
+
             <Button
               size="small"
               variant="outlined"
@@ -0,0 +1,103 @@
+import { useEffect, useState } from "react";
+import {
+  Dialog,
+  DialogTitle,
+  DialogContent,
+  DialogActions,
+  Stack,
+  Button,
+  TextField,
+  MenuItem,
+} from "@mui/material";
+
+const API = process.env.REACT_APP_LOTO_API || "http://localhost:8080";
+
+export const THEMES = [
+  { value: "", label: "No theme" },
+  { value: "tet", label: "🧧 Tết" },
+  { value: "mid_autumn", label: "🏮 Trung Thu" },
+  { value: "christmas", label: "🎄 Christmas" },
+];
+
+export default function RoomInfoDialog({ open, onClose, meta, adminToken }) {
+  const [form, setForm] = useState({});
+
+  // start from the room's current metadata every time the dialog opens
+  useEffect(() => {
+    if (open)
+      setForm({ ...meta, tags: (meta?.tags || []).join(", ") });
+  }, [open]);
+
+  const set = (key) => (e) => setForm((f) => ({ ...f, [key]: e.target.value }));
+
+  const save = async () => {
+    const res = await fetch(`${API}/rooms/meta`, {
+      method: "POST",
+      headers: {
+        Authorization: `Bearer ${adminToken}`,
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({
+        ...form,
+        tags: form.tags.split(",").filter((t) => t.trim()),
+      }),
+    });
+    if (!res.ok) return alert(await res.text());
+    onClose();
+  };
+
+  return (
+    <Dialog open={open} onClose={onClose} fullWidth maxWidth="xs">
+      <DialogTitle>📝 Room info</DialogTitle>
+      <DialogContent>
+        <Stack spacing={2} sx={{ mt: 1 }}>
+          <TextField
+            size="small"
+            label="Title"
+            value={form.title || ""}
+            onChange={set("title")}
+          />
+          <TextField
+            size="small"
+            label="Description"
+            multiline
+            minRows={2}
+            value={form.description || ""}
+            onChange={set("description")}
+          />
+          <TextField
+            size="small"
+            label="Language (vi, en…)"
+            value={form.language || ""}
+            onChange={set("language")}
+          />
+          <TextField
+            select
+            size="small"
+            label="Theme"
+            value={form.theme || ""}
+            onChange={set("theme")}
+          >
+            {THEMES.map((t) => (
+              <MenuItem key={t.value} value={t.value}>
+                {t.label}
+              </MenuItem>
+            ))}
+          </TextField>
+          <TextField
+            size="small"
+            label="Tags (comma separated)"
+            value={form.tags || ""}
+            onChange={set("tags")}
+          />
+        </Stack>
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose}>Cancel</Button>
+        <Button variant="contained" onClick={save}>
+          Save
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+}
@@ -1,15 +1,145 @@
-import { Dialog, DialogTitle, DialogContent, Typography } from "@mui/material";
+import { useEffect, useState } from "react";
+import {
+  Dialog,
+  DialogTitle,
+  DialogContent,
+  Typography,
+  Stack,
+  Button,
+  Divider,
+} from "@mui/material";
+
+const API = process.env.REACT_APP_LOTO_API || "http://localhost:8080";
+
+export default function UsersDialog({
+  open,
+  onClose,
+  users,
+  admin,
+  coHost,
+  roles,
+  me,
+  adminToken,
+}) {
+  const [bans, setBans] = useState([]);
+
+  const call = (path, method = "POST") =>
+    fetch(`${API}${path}`, {
+      method,
+      headers: { Authorization: `Bearer ${adminToken}` },
+    });
+
+  const loadBans = async () => {
+    if (!adminToken) return;
+    try {
+      const res = await call("/rooms/bans", "GET");
+      if (res.ok) setBans((await res.json()) || []);
+    } catch (e) {
+      console.error("load bans error:", e);
+    }
+  };
+
+  useEffect(() => {
+    if (open) loadBans();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [open, adminToken]);
+
+  const kick = async (u) => {
+    if (!window.confirm(`Kick ${u}?`)) return;
+    await call(`/rooms/kick?user=${encodeURIComponent(u)}`);
+  };
+
+  const ban = async (u) => {
+    const reason = window.prompt(`Ban ${u}? Reason (optional):`);
+    if (reason === null) return;
+    const ip = window.confirm("Also ban their IP address?") ? "1" : "0";
+    await call(
+      `/rooms/ban?user=${encodeURIComponent(u)}&ip=${ip}&reason=${encodeURIComponent(reason)}`
+    );
+    loadBans();
+  };
+
+  const transfer = async (u) => {
+    if (!window.confirm(`Make ${u} the host? You will lose host controls.`)) return;
+    await call(`/rooms/transfer-admin?user=${encodeURIComponent(u)}`);
+  };
+
+  const setCoHost = async (u) => {
+    await call(`/rooms/cohost?user=${encodeURIComponent(u)}`);
+  };
+
+  const setRole = async (u, role) => {
+    await call(`/rooms/role?user=${encodeURIComponent(u)}&role=${role}`);
+  };
+
+  const unban = async (u) => {
+    await call(`/rooms/unban?user=${encodeURIComponent(u)}`);
+    loadBans();
+  };
 
-export default function UsersDialog({ open, onClose, users, admin, me }) {
   return (
     <Dialog open={open} onClose={onClose}>
       <DialogTitle>👥 Người chơi</DialogTitle>
       <DialogContent>
         {Object.keys(users || {}).map((u) => (
-          <Typography key={u} fontWeight={u === me ? "bold" : "normal"}>
-            {u} {u === admin && "👑"} {u === me && "(you)"}
-          </Typography>
+          <Stack key={u} direction="row" spacing={1} alignItems="center">
+            <Typography fontWeight={u === me ? "bold" : "normal"} sx={{ flex: 1 }}>
+              {u} {u === admin && "👑"} {u === coHost && "⭐"}{" "}
+              {roles?.[u] === "moderator" && "🛡️"} {roles?.[u] === "spectator" && "👀"}{" "}
+              {u === me && "(you)"}
+            </Typography>
+
+            {adminToken && u !== me && (
+              <>
+                <Button size="small" onClick={() => setCoHost(u === coHost ? "" : u)}>
+                  {u === coHost ? "Unset co-host" : "Co-host"}
+                </Button>
+                <Button
+                  size="small"
+                  onClick={() =>
+                    setRole(u, roles?.[u] === "moderator" ? "player" : "moderator")
+                  }
+                >
+                  {roles?.[u] === "moderator" ? "Unmod" : "Mod"}
+                </Button>
+                <Button
+                  size="small"
+                  onClick={() =>
+                    setRole(u, roles?.[u] === "spectator" ? "player" : "spectator")
+                  }
+                >
+                  {roles?.[u] === "spectator" ? "Let play" : "Spectate"}
+                </Button>
+                <Button size="small" onClick={() => transfer(u)}>
+                  Make host
+                </Button>
+                <Button size="small" onClick={() => kick(u)}>
+                  Kick
+                </Button>
+                <Button size="small" color="error" onClick={() => ban(u)}>
+                  Ban
+                </Button>
+              </>
+            )}
+          </Stack>
         ))}
+
+        {adminToken && bans.length > 0 && (
+          <>
+            <Divider sx={{ my: 1 }} />
+            <Typography fontWeight="bold">🚫 Banned</Typography>
+            {bans.map((b) => (
+              <Stack key={b.username} direction="row" spacing={1} alignItems="center">
+                <Typography sx={{ flex: 1 }}>
+                  {b.username} {b.ipBanned && "(IP)"} {b.reason && `– ${b.reason}`}
+                </Typography>
+                <Button size="small" onClick={() => unban(b.username)}>
+                  Unban
+                </Button>
+              </Stack>
+            ))}
+          </>
+        )}
       </DialogContent>
     </Dialog>
   );
@@ -6,6 +6,7 @@ import { playWinSound, stopWinSound } from "../utils/winsound";
 export default function WinnerCard({
   winner,
   nums,
+  forced,
   isAdmin,
   onReset,
   voiceOn,
@@ -77,6 +78,12 @@ export default function WinnerCard({
             </Typography>
           </Box>
 
+          {forced?.length > 0 && (
+            <Typography sx={{ mt: 2, fontSize: 14, fontWeight: 700 }}>
+              ⚠️ Numbers forced by an operator this game: {forced.join(", ")}
+            </Typography>
+          )}
+
           {isAdmin && (
             <Box sx={{ mt: 3 }}>
               <Button
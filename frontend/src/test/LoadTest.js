@@ -10,12 +10,13 @@ const randomSuffix = () =>
 
 /* ================= KEEP ADMIN ALIVE ================= */
 
-async function adminPing(roomId, adminUser) {
+async function adminPing(roomId, token) {
   while (true) {
     try {
-      await fetch(
-        `${API}/rooms/ping?id=${roomId}&user=${adminUser}`
-      );
+      await fetch(`${API}/rooms/ping?id=${roomId}`, {
+        method: "POST",
+        headers: { Authorization: `Bearer ${token}` },
+      });
       console.log(`👑 admin ping ${roomId}`);
     } catch (e) {
       console.error("⚠️ admin ping error", roomId, e.message);
@@ -26,10 +27,12 @@ async function adminPing(roomId, adminUser) {
 
 /* ================= KEEP PLAYER ALIVE ================= */
 
-async function playerPoll(roomId, user) {
+async function playerPoll(roomId, user, token) {
   while (true) {
     try {
-      await fetch(`${API}/rooms/state?id=${roomId}`);
+      await fetch(`${API}/rooms/state?id=${roomId}`, {
+        headers: { Authorization: `Bearer ${token}` },
+      });
       console.log(`💓 player alive ${roomId} ${user}`);
     } catch (e) {
       console.error("⚠️ player poll error", roomId);
@@ -58,7 +61,8 @@ async function createRoom(idx) {
   console.log("✅ room created", roomId);
 
   // 🔥 ADMIN MUST PING
-  adminPing(roomId, adminUser);
+  const { token } = await res.json();
+  adminPing(roomId, token);
 
   return { roomId, secret };
 }
@@ -80,7 +84,8 @@ async function joinRoom(room, idx) {
 
   console.log(`👤 ${user} joined ${room.roomId}`);
 
-  playerPoll(room.roomId, user);
+  const { token } = await res.json();
+  playerPoll(room.roomId, user, token);
 }
 
 /* ================= MAIN ================= */
@@ -0,0 +1,3 @@
+module my-source/loto-full/shared
+
+go 1.25.3
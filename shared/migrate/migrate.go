@@ -0,0 +1,312 @@
+// Package migrate applies versioned SQL migrations. The loto API and the
+// chat service share one database, so each keeps its own Set with its own
+// version table and lock.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer
+// build than this one.
+type ErrSchemaTooNew struct {
+	Current int
+	Latest  int
+}
+
+func (e ErrSchemaTooNew) Error() string {
+	return fmt.Sprintf(
+		"database schema version %d is newer than this build supports (%d)",
+		e.Current, e.Latest,
+	)
+}
+
+// Set is one service's migrations.
+type Set struct {
+	// FS holds NNNN_name.up.sql / NNNN_name.down.sql files in Dir.
+	FS  fs.FS
+	Dir string
+	// Table records the applied versions.
+	Table string
+	// LockID is the advisory lock that serialises migrations when several
+	// instances start at once.
+	LockID int64
+}
+
+// Migrations returns the migrations ordered by version.
+func (s Set) Migrations() ([]Migration, error) {
+	files, err := fs.Glob(s.FS, path.Join(s.Dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, f := range files {
+		base := path.Base(f)
+
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: missing .up/.down suffix", base)
+		}
+
+		stem := strings.TrimSuffix(base, "."+direction+".sql")
+		num, name, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name", base)
+		}
+		version, err := strconv.Atoi(num)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", base, err)
+		}
+
+		body, err := fs.ReadFile(s.FS, f)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	res := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s: missing up script", m.Version, m.Name)
+		}
+		res = append(res, *m)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+
+	return res, nil
+}
+
+// Check reports a set whose versions do not run 1, 2, 3… or that has a
+// migration without a down script, so a misnamed file fails the tests
+// instead of a deploy.
+func (s Set) Check() error {
+	ms, err := s.Migrations()
+	if err != nil {
+		return err
+	}
+	for i, m := range ms {
+		if m.Version != i+1 {
+			return fmt.Errorf("migration %04d_%s: expected version %d", m.Version, m.Name, i+1)
+		}
+		if m.Down == "" {
+			return fmt.Errorf("migration %04d_%s: missing down script", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+func (s Set) LatestVersion() (int, error) {
+	ms, err := s.Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	return ms[len(ms)-1].Version, nil
+}
+
+func (s Set) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+s.Table+` (
+			version INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ DEFAULT now()
+		)
+	`)
+	return err
+}
+
+func (s Set) currentVersion(ctx context.Context, conn *sql.Conn) (int, error) {
+	var v int
+	err := conn.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0) FROM `+s.Table+`
+	`).Scan(&v)
+	return v, err
+}
+
+// SchemaVersion reports the highest applied migration, 0 for a fresh database.
+func (s Set) SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err := s.ensureTable(ctx, conn); err != nil {
+		return 0, err
+	}
+	return s.currentVersion(ctx, conn)
+}
+
+// withLock runs fn on a single connection holding the advisory lock.
+func (s Set) withLock(ctx context.Context, db *sql.DB, fn func(*sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, s.LockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, s.LockID)
+
+	if err := s.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func runMigration(ctx context.Context, conn *sql.Conn, script string, record func(*sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Pending returns the migrations to apply on top of version current, or
+// ErrSchemaTooNew if current is past the last of ms.
+func Pending(ms []Migration, current int) ([]Migration, error) {
+	latest := 0
+	if len(ms) > 0 {
+		latest = ms[len(ms)-1].Version
+	}
+	if current > latest {
+		return nil, ErrSchemaTooNew{Current: current, Latest: latest}
+	}
+
+	var res []Migration
+	for _, m := range ms {
+		if m.Version > current {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+// Migrate applies every pending migration in order, each in its own
+// transaction. It refuses to touch a schema newer than this build.
+func (s Set) Migrate(ctx context.Context, db *sql.DB) error {
+	ms, err := s.Migrations()
+	if err != nil {
+		return err
+	}
+
+	return s.withLock(ctx, db, func(conn *sql.Conn) error {
+		current, err := s.currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		pending, err := Pending(ms, current)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range pending {
+			err := runMigration(ctx, conn, m.Up, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `
+					INSERT INTO `+s.Table+` (version, name) VALUES ($1, $2)
+				`, m.Version, m.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+			}
+			log.Printf("⬆️ migrated %04d_%s\n", m.Version, m.Name)
+		}
+
+		return nil
+	})
+}
+
+// Rollback reverts the last steps applied migrations.
+func (s Set) Rollback(ctx context.Context, db *sql.DB, steps int) error {
+	ms, err := s.Migrations()
+	if err != nil {
+		return err
+	}
+
+	byVersion := map[int]Migration{}
+	for _, m := range ms {
+		byVersion[m.Version] = m
+	}
+
+	return s.withLock(ctx, db, func(conn *sql.Conn) error {
+		for i := 0; i < steps; i++ {
+			current, err := s.currentVersion(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if current == 0 {
+				return nil
+			}
+
+			m, ok := byVersion[current]
+			if !ok {
+				latest := 0
+				if len(ms) > 0 {
+					latest = ms[len(ms)-1].Version
+				}
+				return ErrSchemaTooNew{Current: current, Latest: latest}
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %04d_%s has no down script", m.Version, m.Name)
+			}
+
+			err = runMigration(ctx, conn, m.Down, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `
+					DELETE FROM `+s.Table+` WHERE version = $1
+				`, m.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+			}
+			log.Printf("⬇️ rolled back %04d_%s\n", m.Version, m.Name)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigrationsOrdersAndPairs(t *testing.T) {
+	s := Set{Dir: "m", FS: fstest.MapFS{
+		"m/0002_b.up.sql":   {Data: []byte("B")},
+		"m/0001_a.up.sql":   {Data: []byte("A")},
+		"m/0001_a.down.sql": {Data: []byte("-A")},
+	}}
+
+	ms, err := s.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 2 {
+		t.Fatalf("got %d migrations, want 2", len(ms))
+	}
+	if ms[0].Version != 1 || ms[0].Name != "a" || ms[0].Up != "A" || ms[0].Down != "-A" {
+		t.Errorf("first migration = %+v", ms[0])
+	}
+	if ms[1].Version != 2 || ms[1].Down != "" {
+		t.Errorf("second migration = %+v", ms[1])
+	}
+
+	latest, err := s.LatestVersion()
+	if err != nil || latest != 2 {
+		t.Errorf("LatestVersion = %d, %v; want 2", latest, err)
+	}
+}
+
+func TestMigrationsRejectsBadFiles(t *testing.T) {
+	for name, file := range map[string]string{
+		"no direction": "m/0001_a.sql",
+		"no name":      "m/0001.up.sql",
+		"bad version":  "m/x_a.up.sql",
+		"down only":    "m/0001_a.down.sql",
+	} {
+		s := Set{Dir: "m", FS: fstest.MapFS{file: {Data: []byte("SELECT 1")}}}
+		if _, err := s.Migrations(); err == nil {
+			t.Errorf("%s: %s accepted", name, file)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"gap": {
+			"m/0001_a.up.sql":   {Data: []byte("A")},
+			"m/0001_a.down.sql": {Data: []byte("-A")},
+			"m/0003_c.up.sql":   {Data: []byte("C")},
+			"m/0003_c.down.sql": {Data: []byte("-C")},
+		},
+		"no down": {
+			"m/0001_a.up.sql": {Data: []byte("A")},
+		},
+		"not from 1": {
+			"m/0002_b.up.sql":   {Data: []byte("B")},
+			"m/0002_b.down.sql": {Data: []byte("-B")},
+		},
+	}
+	for name, files := range tests {
+		if err := (Set{Dir: "m", FS: files}).Check(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	ok := fstest.MapFS{
+		"m/0001_a.up.sql":   {Data: []byte("A")},
+		"m/0001_a.down.sql": {Data: []byte("-A")},
+		"m/0002_b.up.sql":   {Data: []byte("B")},
+		"m/0002_b.down.sql": {Data: []byte("-B")},
+	}
+	if err := (Set{Dir: "m", FS: ok}).Check(); err != nil {
+		t.Errorf("valid set: %v", err)
+	}
+}
+
+func TestPending(t *testing.T) {
+	ms := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+
+	tests := []struct {
+		current int
+		want    []int
+	}{
+		{0, []int{1, 2, 3}},
+		{2, []int{3}},
+		{3, nil},
+	}
+	for _, tt := range tests {
+		got, err := Pending(ms, tt.current)
+		if err != nil {
+			t.Fatalf("Pending(%d): %v", tt.current, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("Pending(%d) = %v, want versions %v", tt.current, got, tt.want)
+		}
+		for i, m := range got {
+			if m.Version != tt.want[i] {
+				t.Errorf("Pending(%d)[%d] = %d, want %d", tt.current, i, m.Version, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestPendingSchemaTooNew(t *testing.T) {
+	_, err := Pending([]Migration{{Version: 1}, {Version: 2}}, 5)
+
+	var tooNew ErrSchemaTooNew
+	if !errors.As(err, &tooNew) {
+		t.Fatalf("err = %v, want ErrSchemaTooNew", err)
+	}
+	if tooNew.Current != 5 || tooNew.Latest != 2 {
+		t.Errorf("err = %+v, want current 5, latest 2", tooNew)
+	}
+
+	if _, err := Pending(nil, 1); !errors.As(err, &tooNew) {
+		t.Errorf("no migrations, version 1: err = %v, want ErrSchemaTooNew", err)
+	}
+}
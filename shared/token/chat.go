@@ -0,0 +1,26 @@
+package token
+
+import "time"
+
+// ChatClaims is the payload of a chat membership token. The loto API signs
+// one on join and ping; the chat server verifies it with the same secret
+// and needs no call back to the API.
+type ChatClaims struct {
+	Room string `json:"room"`
+	User string `json:"user"`
+	Exp  int64  `json:"exp"`
+	// Mod lets the holder delete other members' messages.
+	Mod bool `json:"mod,omitempty"`
+}
+
+// VerifyChat checks a chat membership token and its expiry.
+func VerifyChat(secret []byte, tok string) (ChatClaims, error) {
+	var c ChatClaims
+	if err := Verify(secret, tok, &c); err != nil {
+		return c, err
+	}
+	if c.Room == "" || c.User == "" || time.Now().Unix() >= c.Exp {
+		return c, ErrInvalid
+	}
+	return c, nil
+}
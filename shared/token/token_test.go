@@ -0,0 +1,84 @@
+package token
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClaims struct {
+	Room string `json:"room"`
+	Exp  int64  `json:"exp"`
+}
+
+func TestSignVerify(t *testing.T) {
+	secret := []byte("s3cret")
+	tok, err := Sign(secret, testClaims{Room: "r1", Exp: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got testClaims
+	if err := Verify(secret, tok, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (testClaims{Room: "r1", Exp: 42}) {
+		t.Errorf("claims = %+v", got)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	secret := []byte("s3cret")
+	tok, _ := Sign(secret, testClaims{Room: "r1"})
+	other, _ := Sign(secret, testClaims{Room: "r2"})
+	body, sig, _ := strings.Cut(tok, ".")
+	otherBody, _, _ := strings.Cut(other, ".")
+
+	tests := map[string]struct {
+		secret []byte
+		token  string
+	}{
+		"wrong secret":    {[]byte("other"), tok},
+		"empty secret":    {nil, tok},
+		"swapped payload": {secret, otherBody + "." + sig},
+		"truncated mac":   {secret, body + "." + sig[:len(sig)-2]},
+		"no signature":    {secret, body},
+		"empty":           {secret, ""},
+		"bad base64":      {secret, "!!." + sig},
+	}
+	for name, tt := range tests {
+		var c testClaims
+		if err := Verify(tt.secret, tt.token, &c); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestVerifyChat(t *testing.T) {
+	secret := []byte("s3cret")
+	exp := time.Now().Add(time.Minute).Unix()
+	sign := func(c ChatClaims) string {
+		tok, err := Sign(secret, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	got, err := VerifyChat(secret, sign(ChatClaims{Room: "r1", User: "ann", Exp: exp, Mod: true}))
+	if err != nil || got != (ChatClaims{Room: "r1", User: "ann", Exp: exp, Mod: true}) {
+		t.Errorf("VerifyChat = %+v, %v", got, err)
+	}
+
+	tests := map[string]ChatClaims{
+		"expired": {Room: "r1", User: "ann", Exp: time.Now().Unix()},
+		"no room": {User: "ann", Exp: exp},
+		"no user": {Room: "r1", Exp: exp},
+	}
+	for name, c := range tests {
+		if _, err := VerifyChat(secret, sign(c)); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", name, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy decides which browser origins may call the API.
+type CORSPolicy struct {
+	// AllowedOrigins lists exact origins ("https://loto.example.com"),
+	// subdomain wildcards ("https://*.example.com") or "*" for any.
+	AllowedOrigins   []string
+	AllowCredentials bool
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	// MaxAge is how long browsers may cache a preflight answer.
+	MaxAge time.Duration
+}
+
+var corsPolicy = CORSPolicy{
+	AllowedOrigins: []string{"*"},
+	AllowedMethods: []string{"GET", "POST", "DELETE", "OPTIONS"},
+	AllowedHeaders: []string{"Content-Type", "Authorization"},
+	MaxAge:         10 * time.Minute,
+}
+
+// SetCORSPolicy replaces the policy WithCORS applies. Call it before
+// serving.
+func SetCORSPolicy(p CORSPolicy) {
+	corsPolicy = p
+}
+
+// CORSPolicyFromEnv starts from the default policy (any origin, no
+// credentials) and applies CORS_ALLOWED_ORIGINS (comma separated),
+// CORS_ALLOW_CREDENTIALS and CORS_MAX_AGE (seconds).
+func CORSPolicyFromEnv() (CORSPolicy, error) {
+	p := corsPolicy
+
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		p.AllowedOrigins = nil
+		for _, o := range strings.Split(v, ",") {
+			if o = normalizeOrigin(o); o != "" {
+				p.AllowedOrigins = append(p.AllowedOrigins, o)
+			}
+		}
+	}
+
+	if v := os.Getenv("CORS_ALLOW_CREDENTIALS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, errors.New("invalid CORS_ALLOW_CREDENTIALS: " + v)
+		}
+		p.AllowCredentials = b
+	}
+
+	if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, errors.New("invalid CORS_MAX_AGE: " + v)
+		}
+		p.MaxAge = time.Duration(n) * time.Second
+	}
+
+	// a credentialed wildcard would let any site act as the user
+	if p.AllowCredentials && p.allowsAny() {
+		return p, errors.New("CORS_ALLOW_CREDENTIALS needs an explicit CORS_ALLOWED_ORIGINS list")
+	}
+	return p, nil
+}
+
+func (p CORSPolicy) allowsAny() bool {
+	for _, o := range p.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p CORSPolicy) allowsOrigin(origin string) bool {
+	origin = normalizeOrigin(origin)
+	for _, o := range p.AllowedOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+
+		// "https://*.example.com" matches any subdomain, not the apex
+		if scheme, host, ok := strings.Cut(o, "://*."); ok {
+			prefix := scheme + "://"
+			if strings.HasPrefix(origin, prefix) &&
+				strings.HasSuffix(origin, "."+host) &&
+				len(origin) > len(prefix)+len(host)+1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizeOrigin(o string) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(o), "/"))
+}
+
+// WithCORS applies the configured policy. Requests from origins outside
+// the allowlist are still served but get no CORS headers, so browsers
+// refuse to hand the response to the calling page; their preflights get
+// 403.
+func WithCORS(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := corsPolicy
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+
+		allowed := origin != "" && p.allowsOrigin(origin)
+		if allowed {
+			if p.allowsAny() && !p.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if p.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
+		}
+
+		if r.Method != http.MethodOptions {
+			h(w, r)
+			return
+		}
+
+		if origin != "" && !allowed {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+			if p.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,113 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowsOrigin(t *testing.T) {
+	p := CORSPolicy{AllowedOrigins: []string{"https://loto.example.com", "https://*.example.org"}}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://loto.example.com", true},
+		{"HTTPS://LOTO.EXAMPLE.COM/", true},
+		{"http://loto.example.com", false},
+		{"https://evil.com", false},
+		{"https://loto.example.com.evil.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evilexample.org", false},
+		{"http://a.example.org", false},
+	}
+	for _, tt := range tests {
+		if got := p.allowsOrigin(tt.origin); got != tt.want {
+			t.Errorf("allowsOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORSPolicyFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://A.example.com/ ,https://b.example.com")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("CORS_MAX_AGE", "60")
+
+	p, err := CORSPolicyFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.AllowedOrigins) != 2 || p.AllowedOrigins[0] != "https://a.example.com" {
+		t.Errorf("AllowedOrigins = %v", p.AllowedOrigins)
+	}
+	if !p.AllowCredentials || p.MaxAge.Seconds() != 60 {
+		t.Errorf("policy = %+v", p)
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	if _, err := CORSPolicyFromEnv(); err == nil {
+		t.Error("credentials with a wildcard origin accepted")
+	}
+
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	t.Setenv("CORS_MAX_AGE", "-1")
+	if _, err := CORSPolicyFromEnv(); err == nil {
+		t.Error("negative CORS_MAX_AGE accepted")
+	}
+}
+
+func TestWithCORS(t *testing.T) {
+	old := corsPolicy
+	t.Cleanup(func() { SetCORSPolicy(old) })
+	SetCORSPolicy(CORSPolicy{
+		AllowedOrigins:   []string{"https://loto.example.com"},
+		AllowCredentials: true,
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization"},
+	})
+
+	served := false
+	h := WithCORS(func(w http.ResponseWriter, r *http.Request) { served = true })
+
+	do := func(method, origin string) *httptest.ResponseRecorder {
+		served = false
+		r := httptest.NewRequest(method, "/", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+
+	w := do("GET", "https://loto.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://loto.example.com" {
+		t.Errorf("allowed origin: Allow-Origin = %q", got)
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("allowed origin: credentials not allowed")
+	}
+
+	w = do("GET", "https://evil.com")
+	if !served {
+		t.Error("disallowed origin: request not served")
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("disallowed origin: Allow-Origin = %q", got)
+	}
+
+	if w := do("OPTIONS", "https://evil.com"); w.Code != http.StatusForbidden || served {
+		t.Errorf("disallowed preflight: %d, served %v", w.Code, served)
+	}
+
+	w = do("OPTIONS", "https://loto.example.com")
+	if w.Code != http.StatusNoContent || served {
+		t.Errorf("preflight: %d, served %v", w.Code, served)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Errorf("preflight: Allow-Methods = %q", got)
+	}
+}
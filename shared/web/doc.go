@@ -0,0 +1,4 @@
+// Package web holds the HTTP plumbing the loto API and the chat service
+// share: client IP resolution behind trusted proxies, per-route rate
+// limiting and the CORS policy.
+package web
@@ -0,0 +1,153 @@
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+var (
+	proxiesMu      sync.RWMutex
+	trustedProxies []netip.Prefix
+)
+
+// SetTrustedProxies configures which peers may report the client address
+// through forwarding headers: a comma separated list of CIDRs or single
+// IPs, e.g. "10.0.0.0/8,127.0.0.1". Empty trusts nobody.
+func SetTrustedProxies(spec string) error {
+	var list []netip.Prefix
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		if strings.Contains(s, "/") {
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return fmt.Errorf("trusted proxy %q: %w", s, err)
+			}
+			list = append(list, p.Masked())
+			continue
+		}
+
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return fmt.Errorf("trusted proxy %q: %w", s, err)
+		}
+		a = a.Unmap()
+		list = append(list, netip.PrefixFrom(a, a.BitLen()))
+	}
+
+	proxiesMu.Lock()
+	trustedProxies = list
+	proxiesMu.Unlock()
+	return nil
+}
+
+func isTrustedProxy(a netip.Addr) bool {
+	a = a.Unmap()
+
+	proxiesMu.RLock()
+	defer proxiesMu.RUnlock()
+
+	for _, p := range trustedProxies {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClientIP returns the address of the client behind any trusted
+// proxies. Forwarding headers are only read when the direct peer is a
+// trusted proxy; the hop list (RFC 7239 Forwarded, else X-Forwarded-For)
+// is then walked right to left and the first untrusted hop wins.
+func GetClientIP(r *http.Request) string {
+	peer, ok := parseHost(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if !isTrustedProxy(peer) {
+		return peer.String()
+	}
+
+	hops := forwardedFor(r.Header)
+	if len(hops) == 0 {
+		hops = splitList(r.Header.Values("X-Forwarded-For"))
+	}
+	if len(hops) == 0 {
+		// single-value headers set by the proxy itself
+		for _, h := range []string{"X-Real-IP", "CF-Connecting-IP"} {
+			if a, ok := parseHost(r.Header.Get(h)); ok {
+				return a.String()
+			}
+		}
+		return peer.String()
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		a, ok := parseHost(hops[i])
+		if !ok {
+			// "unknown" or an obfuscated name: the last proxy that
+			// reported it is as far as we can see
+			break
+		}
+		client = a
+		if !isTrustedProxy(a) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedFor returns the for= parameters of RFC 7239 Forwarded headers,
+// nearest hop last.
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	for _, elem := range splitList(h.Values("Forwarded")) {
+		for _, pair := range strings.Split(elem, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(k, "for") {
+				hops = append(hops, strings.Trim(v, `"`))
+			}
+		}
+	}
+	return hops
+}
+
+func splitList(values []string) []string {
+	var res []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
+
+// parseHost accepts "ip", "ip:port", "[ipv6]" and "[ipv6]:port".
+func parseHost(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return netip.Addr{}, false
+	}
+
+	if a, err := netip.ParseAddr(s); err == nil {
+		return a.Unmap(), true
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	a, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return a.Unmap(), true
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetClientIP(t *testing.T) {
+	if err := SetTrustedProxies("10.0.0.0/8, 127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetTrustedProxies("") })
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"direct client", "203.0.113.7:4000", nil, "203.0.113.7"},
+		{
+			"untrusted peer cannot spoof",
+			"203.0.113.7:4000",
+			map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			"203.0.113.7",
+		},
+		{
+			"trusted proxy",
+			"10.1.2.3:80",
+			map[string]string{"X-Forwarded-For": "198.51.100.9"},
+			"198.51.100.9",
+		},
+		{
+			"spoofed left-most hop is skipped",
+			"10.1.2.3:80",
+			map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.9, 10.9.9.9"},
+			"198.51.100.9",
+		},
+		{
+			"all hops trusted",
+			"127.0.0.1:80",
+			map[string]string{"X-Forwarded-For": "10.0.0.5"},
+			"10.0.0.5",
+		},
+		{
+			"forwarded header wins",
+			"10.1.2.3:80",
+			map[string]string{
+				"Forwarded":       `for="[2001:db8::1]:443";proto=https`,
+				"X-Forwarded-For": "198.51.100.9",
+			},
+			"2001:db8::1",
+		},
+		{
+			"unknown hop stops the walk",
+			"10.1.2.3:80",
+			map[string]string{"X-Forwarded-For": "198.51.100.9, unknown"},
+			"10.1.2.3",
+		},
+		{
+			"real ip header",
+			"10.1.2.3:80",
+			map[string]string{"X-Real-IP": "198.51.100.10"},
+			"198.51.100.10",
+		},
+		{"mapped ipv4 peer", "[::ffff:203.0.113.7]:4000", nil, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remote
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		if got := GetClientIP(r); got != tt.want {
+			t.Errorf("%s: GetClientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetTrustedProxiesRejectsGarbage(t *testing.T) {
+	t.Cleanup(func() { SetTrustedProxies("") })
+
+	for _, spec := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if err := SetTrustedProxies(spec); err == nil {
+			t.Errorf("SetTrustedProxies(%q) accepted", spec)
+		}
+	}
+}
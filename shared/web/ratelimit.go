@@ -0,0 +1,243 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limit is a token bucket holding Burst requests, refilled at Burst per
+// Per. The zero Limit means unlimited.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+func (l Limit) Off() bool { return l.Burst <= 0 || l.Per <= 0 }
+
+func (l Limit) String() string {
+	if l.Off() {
+		return "off"
+	}
+	switch l.Per {
+	case time.Second:
+		return strconv.Itoa(l.Burst) + "/s"
+	case time.Minute:
+		return strconv.Itoa(l.Burst) + "/m"
+	case time.Hour:
+		return strconv.Itoa(l.Burst) + "/h"
+	}
+	return strconv.Itoa(l.Burst) + "/" + l.Per.String()
+}
+
+// ParseLimit reads "<n>/<s|m|h>" (e.g. "5/m") or "off".
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+
+	n, unit, ok := strings.Cut(s, "/")
+	burst, err := strconv.Atoi(n)
+	if !ok || err != nil || burst < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	per := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}[unit]
+	if per == 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit unit in %q", s)
+	}
+	return Limit{Burst: burst, Per: per}, nil
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// SessionKey names the session a request belongs to, "" for none.
+type SessionKey func(r *http.Request) string
+
+// RateLimiter guards one route with a bucket per client IP and, for
+// requests that belong to a session, a bucket per session. State is kept
+// in memory, so each instance limits on its own.
+type RateLimiter struct {
+	Name       string
+	PerIP      Limit
+	PerSession Limit
+	Session    SessionKey
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	rejectedIP      atomic.Int64
+	rejectedSession atomic.Int64
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   []*RateLimiter
+	sweepOnce  sync.Once
+)
+
+// NewRateLimiter registers a limiter for name. RATE_LIMIT_<NAME>_IP and
+// RATE_LIMIT_<NAME>_SESSION override the defaults, e.g.
+// RATE_LIMIT_ROOMS_CREATE_IP=10/m.
+func NewRateLimiter(name string, perIP, perSession Limit, session SessionKey) *RateLimiter {
+	env := "RATE_LIMIT_" + strings.ToUpper(name)
+	l := &RateLimiter{
+		Name:       name,
+		PerIP:      envLimit(env+"_IP", perIP),
+		PerSession: envLimit(env+"_SESSION", perSession),
+		Session:    session,
+		buckets:    make(map[string]*bucket),
+	}
+
+	limitersMu.Lock()
+	limiters = append(limiters, l)
+	limitersMu.Unlock()
+
+	sweepOnce.Do(func() { go sweepLimiters() })
+	return l
+}
+
+func envLimit(key string, def Limit) Limit {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	l, err := ParseLimit(v)
+	if err != nil {
+		log.Println("⚠️", key+":", err, "- using", def)
+		return def
+	}
+	return l
+}
+
+// allow takes a token from key's bucket. When empty it reports how long
+// until the next token.
+func (l *RateLimiter) allow(key string, limit Limit, now time.Time) (bool, time.Duration) {
+	if limit.Off() {
+		return true, 0
+	}
+
+	rate := float64(limit.Burst) / limit.Per.Seconds()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// Wrap rejects over-limit requests with 429 and Retry-After.
+func (l *RateLimiter) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+
+		if ok, wait := l.allow("ip:"+GetClientIP(r), l.PerIP, now); !ok {
+			l.rejectedIP.Add(1)
+			tooManyRequests(w, wait)
+			return
+		}
+
+		if key := l.Session(r); key != "" {
+			if ok, wait := l.allow("session:"+key, l.PerSession, now); !ok {
+				l.rejectedSession.Add(1)
+				tooManyRequests(w, wait)
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+func tooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	secs := int(math.Ceil(wait.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	http.Error(w, "too many requests", http.StatusTooManyRequests)
+}
+
+// sweepLimiters drops buckets that have refilled completely; they hold
+// nothing a fresh bucket would not.
+func sweepLimiters() {
+	for {
+		time.Sleep(time.Minute)
+		now := time.Now()
+
+		limitersMu.Lock()
+		list := append([]*RateLimiter(nil), limiters...)
+		limitersMu.Unlock()
+
+		for _, l := range list {
+			idle := max(l.PerIP.Per, l.PerSession.Per)
+			l.mu.Lock()
+			for k, b := range l.buckets {
+				if now.Sub(b.last) >= idle {
+					delete(l.buckets, k)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// RateLimitStat is one limiter's configuration and rejection counters.
+type RateLimitStat struct {
+	Route           string `json:"route"`
+	PerIP           string `json:"perIp"`
+	PerSession      string `json:"perSession"`
+	RejectedIP      int64  `json:"rejectedIp"`
+	RejectedSession int64  `json:"rejectedSession"`
+	TrackedKeys     int    `json:"trackedKeys"`
+}
+
+// RateLimitStats reports every registered limiter, sorted by route.
+func RateLimitStats() []RateLimitStat {
+	limitersMu.Lock()
+	list := append([]*RateLimiter(nil), limiters...)
+	limitersMu.Unlock()
+
+	res := make([]RateLimitStat, 0, len(list))
+	for _, l := range list {
+		l.mu.Lock()
+		n := len(l.buckets)
+		l.mu.Unlock()
+
+		res = append(res, RateLimitStat{
+			Route:           l.Name,
+			PerIP:           l.PerIP.String(),
+			PerSession:      l.PerSession.String(),
+			RejectedIP:      l.rejectedIP.Load(),
+			RejectedSession: l.rejectedSession.Load(),
+			TrackedKeys:     n,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Route < res[j].Route })
+	return res
+}
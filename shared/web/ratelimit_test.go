@@ -0,0 +1,133 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+	}{
+		{"5/m", Limit{Burst: 5, Per: time.Minute}},
+		{" 20/s ", Limit{Burst: 20, Per: time.Second}},
+		{"100/h", Limit{Burst: 100, Per: time.Hour}},
+		{"off", Limit{}},
+		{"0", Limit{}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"5", "5/d", "-1/m", "x/m"} {
+		if _, err := ParseLimit(in); err == nil {
+			t.Errorf("ParseLimit(%q) accepted", in)
+		}
+	}
+}
+
+func TestRateLimiterBucket(t *testing.T) {
+	l := &RateLimiter{buckets: map[string]*bucket{}}
+	limit := Limit{Burst: 2, Per: time.Second}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("k", limit, now); !ok {
+			t.Fatalf("request %d rejected within the burst", i+1)
+		}
+	}
+	ok, wait := l.allow("k", limit, now)
+	if ok {
+		t.Fatal("request past the burst allowed")
+	}
+	if wait <= 0 || wait > time.Second/2 {
+		t.Errorf("wait = %v, want about half a second", wait)
+	}
+
+	if ok, _ := l.allow("other", limit, now); !ok {
+		t.Error("a different key shares the bucket")
+	}
+	if ok, _ := l.allow("k", limit, now.Add(time.Second/2)); !ok {
+		t.Error("bucket did not refill")
+	}
+	if ok, _ := l.allow("k", Limit{}, now); !ok {
+		t.Error("an off limit rejected a request")
+	}
+}
+
+func TestRateLimiterWrap(t *testing.T) {
+	l := NewRateLimiter("test_wrap", Limit{Burst: 1, Per: time.Minute}, Limit{}, func(*http.Request) string { return "" })
+	h := l.Wrap(func(w http.ResponseWriter, r *http.Request) {})
+
+	do := func(remote string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+
+	if w := do("203.0.113.1:1"); w.Code != http.StatusOK {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	w := do("203.0.113.1:2")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("429 without Retry-After")
+	}
+	if w := do("203.0.113.2:1"); w.Code != http.StatusOK {
+		t.Errorf("another client: %d", w.Code)
+	}
+	if n := l.rejectedIP.Load(); n != 1 {
+		t.Errorf("rejectedIP = %d, want 1", n)
+	}
+}
+
+func TestRateLimiterPerSession(t *testing.T) {
+	l := NewRateLimiter("test_session", Limit{}, Limit{Burst: 1, Per: time.Minute}, func(r *http.Request) string {
+		return r.Header.Get("Authorization")
+	})
+	h := l.Wrap(func(w http.ResponseWriter, r *http.Request) {})
+
+	do := func(remote, session string) int {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.RemoteAddr = remote
+		r.Header.Set("Authorization", session)
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w.Code
+	}
+
+	if code := do("203.0.113.1:1", "a"); code != http.StatusOK {
+		t.Fatalf("first request: %d", code)
+	}
+	// changing address does not reset the session's bucket
+	if code := do("203.0.113.2:1", "a"); code != http.StatusTooManyRequests {
+		t.Errorf("same session, other IP: %d, want 429", code)
+	}
+	if code := do("203.0.113.1:1", "b"); code != http.StatusOK {
+		t.Errorf("other session: %d", code)
+	}
+}
+
+func TestRateLimiterEnvOverride(t *testing.T) {
+	t.Setenv("RATE_LIMIT_TEST_ENV_IP", "7/h")
+	t.Setenv("RATE_LIMIT_TEST_ENV_SESSION", "bogus")
+
+	def := Limit{Burst: 1, Per: time.Second}
+	l := NewRateLimiter("test_env", def, def, nil)
+	if want := (Limit{Burst: 7, Per: time.Hour}); l.PerIP != want {
+		t.Errorf("PerIP = %v, want %v", l.PerIP, want)
+	}
+	if l.PerSession != def {
+		t.Errorf("PerSession = %v, want the default on a bad value", l.PerSession)
+	}
+}